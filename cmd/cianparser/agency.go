@@ -0,0 +1,130 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"gonum.org/v1/gonum/stat"
+)
+
+type ownerKey struct {
+	Location   string
+	RoomsCount int
+}
+
+type agencyStatItem struct {
+	Name                string  `json:"name"`
+	AgentsCount         int     `json:"agents_count"`
+	OffersCount         int     `json:"offers_count"`
+	ComparedOffersCount int     `json:"compared_offers_count"`
+	MedianMarkup        float64 `json:"median_markup"`
+}
+
+// getAgencyStatistic returns top agencies by offers count with median markup of
+// their price per meter over owners' median in the same location and rooms count,
+// agents are grouped by agency name, agents without agency are skipped
+func getAgencyStatistic(offers []cian.Offer, topCount int) []agencyStatItem {
+	ownerPrices := make(map[ownerKey][]float64)
+	for _, offer := range offers {
+		if offer.SellerType() != cian.SellerTypeOwner {
+			continue
+		}
+
+		pricePerMeter, err := offer.PricePerMeter()
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		key := ownerKey{
+			Location:   offer.Location,
+			RoomsCount: int(offer.RoomsCount),
+		}
+
+		ownerPrices[key] = append(ownerPrices[key], pricePerMeter)
+	}
+
+	ownerMedians := make(map[ownerKey]float64, len(ownerPrices))
+	for key, value := range ownerPrices {
+		sort.Float64s(value)
+		ownerMedians[key] = stat.Quantile(0.5, stat.Empirical, value, nil)
+	}
+
+	agents := make(map[string]map[int64]struct{})
+	counts := make(map[string]int)
+	markups := make(map[string][]float64)
+	for _, offer := range offers {
+		if offer.SellerType() != cian.SellerTypeAgent {
+			continue
+		}
+
+		name := strings.TrimSpace(offer.User.AgencyName)
+		if name == "" {
+			continue
+		}
+
+		if agents[name] == nil {
+			agents[name] = make(map[int64]struct{})
+		}
+		agents[name][offer.UserID] = struct{}{}
+		counts[name]++
+
+		pricePerMeter, err := offer.PricePerMeter()
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		ownerMedian, ok := ownerMedians[ownerKey{
+			Location:   offer.Location,
+			RoomsCount: int(offer.RoomsCount),
+		}]
+		if !ok || ownerMedian == 0 {
+			continue
+		}
+
+		markups[name] = append(markups[name], pricePerMeter/ownerMedian-1)
+	}
+
+	agencyStat := make([]agencyStatItem, 0, len(counts))
+	for name, count := range counts {
+		item := agencyStatItem{
+			Name:                name,
+			AgentsCount:         len(agents[name]),
+			OffersCount:         count,
+			ComparedOffersCount: len(markups[name]),
+		}
+
+		if len(markups[name]) > 0 {
+			sort.Float64s(markups[name])
+			item.MedianMarkup = stat.Quantile(0.5, stat.Empirical, markups[name], nil)
+		}
+
+		agencyStat = append(agencyStat, item)
+	}
+
+	sort.Slice(agencyStat, func(i, j int) bool {
+		if agencyStat[i].OffersCount != agencyStat[j].OffersCount {
+			return agencyStat[i].OffersCount > agencyStat[j].OffersCount
+		}
+		return agencyStat[i].Name < agencyStat[j].Name
+	})
+
+	if topCount > 0 && len(agencyStat) > topCount {
+		agencyStat = agencyStat[:topCount]
+	}
+
+	return agencyStat
+}
+
+func saveAgencyStatistic(storage Storage, timestamp time.Time, statistic []agencyStatItem) error {
+	rows := make([][]any, 0)
+	for _, row := range statistic {
+		rows = append(rows, []any{timestamp.UTC(), row.Name, row.AgentsCount, row.OffersCount, row.ComparedOffersCount, row.MedianMarkup})
+	}
+
+	return storage.Insert("agency_statistic", []string{"date_time", "agency_name", "agents_count", "offers_count", "compared_offers_count", "median_markup"}, rows)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/geo"
+)
+
+const (
+	outsidePolygonDrop = "drop"
+	outsidePolygonTag  = "tag"
+)
+
+// filterOffersByArea marks offers outside of area, they are removed in drop mode
+// and kept only for lifecycles in tag mode,
+// returns kept offers and offers outside of area
+func filterOffersByArea(offers []cian.Offer, area *geo.Area, mode string) ([]cian.Offer, []cian.Offer, error) {
+	if mode != outsidePolygonDrop && mode != outsidePolygonTag {
+		return nil, nil, fmt.Errorf("unknown outside polygon mode: %s", mode)
+	}
+
+	kept := make([]cian.Offer, 0, len(offers))
+	outside := make([]cian.Offer, 0)
+	for _, offer := range offers {
+		if !area.Contains(offer.Geo.Coordinates.Lng, offer.Geo.Coordinates.Lat) {
+			offer.OutsidePolygon = true
+			outside = append(outside, offer)
+
+			if mode == outsidePolygonDrop {
+				continue
+			}
+		}
+
+		kept = append(kept, offer)
+	}
+
+	return kept, outside, nil
+}
+
+// getInsideOffers returns offers which are not tagged as outside of area,
+// tagged offers are kept for lifecycles but don't get to statistics
+func getInsideOffers(offers []cian.Offer) []cian.Offer {
+	inside := make([]cian.Offer, 0, len(offers))
+	for _, offer := range offers {
+		if !offer.OutsidePolygon {
+			inside = append(inside, offer)
+		}
+	}
+
+	return inside
+}
+
+func saveOutsideOffers(storage Storage, timestamp time.Time, offers []cian.Offer, dropped bool) error {
+	rows := make([][]any, 0)
+	for _, offer := range offers {
+		rows = append(rows, []any{timestamp.UTC(), offer.CianID, offer.Geo.Coordinates.Lat, offer.Geo.Coordinates.Lng, dropped})
+	}
+
+	return storage.Insert("offer_outside_polygon", []string{"date_time", "offer_id", "lat", "lng", "dropped"}, rows)
+}
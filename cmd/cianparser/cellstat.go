@@ -0,0 +1,107 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/geo"
+	"gonum.org/v1/gonum/stat"
+)
+
+type cellStatItem struct {
+	CellID      string
+	Geometry    string
+	OffersCount int
+	MedianPrice float64
+	MinPrice    float64
+	MaxPrice    float64
+}
+
+func (item *cellStatItem) addPriceRange(minPrice, maxPrice float64) {
+	if minPrice > 0 && (item.MinPrice == 0 || minPrice < item.MinPrice) {
+		item.MinPrice = minPrice
+	}
+	item.MaxPrice = max(item.MaxPrice, maxPrice)
+}
+
+// getCellStatistic aggregates offers by grid cell, clusters are matched to cells by bounds,
+// offer belongs to cell with its point for geohash and h3 grids and to first cell
+// which has it in clusters for others, h3 cells are searched by hexagon bounds,
+// so their clusters have offers of adjacent hexagons and price range is taken from offers
+func getCellStatistic(grid geo.Grid, cells []geo.Cell, cellClusters []cian.CellClusters, offers []cian.Offer) []cellStatItem {
+	hexagons := grid.Type == geo.GridTypeH3
+
+	statistic := make([]cellStatItem, len(cells))
+	offerCells := make(map[int64]int)
+	cellIndexes := make(map[string]int)
+
+	matched := matchCellClusters(cells, cellClusters)
+	for i, cell := range cells {
+		statistic[i] = cellStatItem{
+			CellID:   cell.ID,
+			Geometry: cell.Geometry.ToWKT(),
+		}
+		cellIndexes[cell.ID] = i
+
+		if matched[i] == nil {
+			continue
+		}
+
+		for _, cluster := range matched[i].Clusters {
+			if !hexagons {
+				statistic[i].addPriceRange(cluster.MinPrice, cluster.MaxPrice)
+			}
+
+			for _, id := range cluster.ClusterOfferIds {
+				if _, ok := offerCells[id]; !ok {
+					offerCells[id] = i
+				}
+			}
+		}
+	}
+
+	pricesPerMeter := make([][]float64, len(cells))
+	for _, offer := range offers {
+		i, ok := offerCells[offer.CianID]
+		if id, exact := grid.CellID(offer.Geo.Coordinates.Lng, offer.Geo.Coordinates.Lat); exact {
+			i, ok = cellIndexes[id]
+		}
+		if !ok {
+			continue
+		}
+
+		pricePerMeter, err := offer.PricePerMeter()
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		pricesPerMeter[i] = append(pricesPerMeter[i], pricePerMeter)
+		if hexagons {
+			statistic[i].addPriceRange(offer.BargainTerms.PriceRur, offer.BargainTerms.PriceRur)
+		}
+	}
+
+	for i, prices := range pricesPerMeter {
+		statistic[i].OffersCount = len(prices)
+		if len(prices) == 0 {
+			continue
+		}
+
+		sort.Float64s(prices)
+		statistic[i].MedianPrice = stat.Quantile(0.5, stat.Empirical, prices, nil)
+	}
+
+	return statistic
+}
+
+func saveCellStatistic(storage Storage, timestamp time.Time, gridType string, statistic []cellStatItem) error {
+	rows := make([][]any, 0)
+	for _, row := range statistic {
+		rows = append(rows, []any{timestamp.UTC(), gridType, row.CellID, row.Geometry, uint32(row.OffersCount), row.MedianPrice, row.MinPrice, row.MaxPrice})
+	}
+
+	return storage.Insert("cell_statistic", []string{"date_time", "grid_type", "cell_id", "geometry", "offers_count", "price_per_meter", "min_price", "max_price"}, rows)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/geo"
+	"github.com/mishannn/cianparser-go/internal/h3"
+)
+
+func TestCellStatisticH3PriceRange(t *testing.T) {
+	geojson, err := geo.ReadArea(testArea, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	polygon, _, err := geo.NormalizeGeoJSON(geojson)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	grid := geo.Grid{Type: geo.GridTypeH3, H3Resolution: 8}
+	cells, err := geo.GetGridCells(polygon, grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cell := cells[0]
+
+	h3Cell, err := h3.ParseCell(cell.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	centerLat, centerLng := h3Cell.LatLng()
+
+	// bounds corner is outside of hexagon
+	cornerLat, cornerLng := cell.Bounds.MinY+1e-6, cell.Bounds.MinX+1e-6
+	if id, _ := grid.CellID(cornerLng, cornerLat); id == cell.ID {
+		t.Fatalf("bounds corner is inside of hexagon %s", cell.ID)
+	}
+
+	offers := []cian.Offer{
+		{CianID: 1, TotalArea: "50", BargainTerms: cian.BargainTerms{PriceRur: 5_000_000}, Geo: cian.Geo{Coordinates: cian.Coordinates{Lat: centerLat, Lng: centerLng}}},
+		{CianID: 2, TotalArea: "50", BargainTerms: cian.BargainTerms{PriceRur: 9_000_000}, Geo: cian.Geo{Coordinates: cian.Coordinates{Lat: cornerLat, Lng: cornerLng}}},
+	}
+	cellClusters := []cian.CellClusters{{
+		Bounds:   cian.GeosBoundsToCianBounds(cell.Bounds),
+		Clusters: []cian.Cluster{{Count: 2, MinPrice: 5_000_000, MaxPrice: 9_000_000, ClusterOfferIds: []int64{1, 2}}},
+	}}
+
+	statistic := getCellStatistic(grid, cells, cellClusters, offers)
+
+	got := statistic[0]
+	if got.OffersCount != 1 || got.MinPrice != 5_000_000 || got.MaxPrice != 5_000_000 {
+		t.Errorf("got %d offers with prices %f-%f, want only offer inside of hexagon", got.OffersCount, got.MinPrice, got.MaxPrice)
+	}
+}
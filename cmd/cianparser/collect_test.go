@@ -0,0 +1,406 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/cian/ciantest"
+	"github.com/mishannn/cianparser-go/internal/geo"
+	"gonum.org/v1/gonum/stat"
+)
+
+const testArea = "49.10,55.77,49.14,55.80"
+
+// memoryStorage keeps inserted rows by table
+type memoryStorage struct {
+	tables     map[string][]map[string]any
+	lifecycles map[int64]offerLifecycle
+}
+
+func newMemoryStorage() *memoryStorage {
+	return &memoryStorage{
+		tables:     make(map[string][]map[string]any),
+		lifecycles: make(map[int64]offerLifecycle),
+	}
+}
+
+func (s *memoryStorage) Insert(table string, columns []string, rows [][]any) error {
+	for _, row := range rows {
+		if len(row) != len(columns) {
+			return fmt.Errorf("%s row has %d values for %d columns", table, len(row), len(columns))
+		}
+
+		values := make(map[string]any, len(columns))
+		for i, column := range columns {
+			values[column] = row[i]
+		}
+		s.tables[table] = append(s.tables[table], values)
+	}
+
+	return nil
+}
+
+func (s *memoryStorage) LoadOfferLifecycles() (map[int64]offerLifecycle, error) {
+	return s.lifecycles, nil
+}
+
+func (s *memoryStorage) Close() error {
+	return nil
+}
+
+func newTestServer(t *testing.T, count int, options ciantest.Options) (*ciantest.Server, []cian.Offer) {
+	t.Helper()
+
+	geojson, err := geo.ReadArea(testArea, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offers, err := ciantest.GenerateOffers(geojson, count, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options.Offers = offers
+	server := ciantest.NewServer(options)
+	t.Cleanup(server.Close)
+
+	return server, offers
+}
+
+func newTestConfig(server *ciantest.Server) *Config {
+	cfg := &Config{}
+	cfg.Cian.BaseURL = server.URL
+	cfg.Cian.SearchType = "flatSale"
+	cfg.Cian.MaxCellSizeMeters = 1000
+	cfg.Cian.MaxWorkersCollectIds = 2
+	cfg.Cian.MaxWorkersCollectOffers = 2
+	cfg.Rucaptcha.APIKey = "test"
+	cfg.Rucaptcha.BaseURL = server.URL
+	cfg.Rucaptcha.PollingInterval = 1
+
+	return cfg
+}
+
+// getExpectedStatistic returns median price per meter by location and rooms count
+func getExpectedStatistic(t *testing.T, offers []cian.Offer) map[string]float64 {
+	t.Helper()
+
+	grouped := make(map[string][]float64)
+	for _, offer := range offers {
+		totalArea, err := offer.GetTotalArea()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		key := fmt.Sprintf("%s/%d", getDistrictString(offer.Geo.Address), offer.RoomsCount)
+		grouped[key] = append(grouped[key], offer.BargainTerms.PriceRur/totalArea)
+	}
+
+	expected := make(map[string]float64, len(grouped))
+	for key, prices := range grouped {
+		sort.Float64s(prices)
+		expected[key] = stat.Quantile(0.5, stat.Empirical, prices, nil)
+	}
+
+	return expected
+}
+
+func TestCollect(t *testing.T) {
+	server, offers := newTestServer(t, 120, ciantest.Options{CaptchaEvery: 10})
+	storage := newMemoryStorage()
+
+	err := collect(newTestConfig(server), collectOptions{Polygon: testArea}, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := server.Stats()
+	if stats.ClusterRequests == 0 {
+		t.Errorf("no cluster requests")
+	}
+	if stats.Captchas == 0 {
+		t.Errorf("no captchas")
+	}
+
+	lifecycles := storage.tables["offer_lifecycle"]
+	if len(lifecycles) != len(offers) {
+		t.Fatalf("got %d offer lifecycles, want %d", len(lifecycles), len(offers))
+	}
+
+	cellOffers := 0
+	for _, row := range storage.tables["cell_statistic"] {
+		cellOffers += int(row["offers_count"].(uint32))
+	}
+	if cellOffers != len(offers) {
+		t.Errorf("cell statistic has %d offers, want %d", cellOffers, len(offers))
+	}
+
+	expected := getExpectedStatistic(t, offers)
+
+	statistic := storage.tables["flat_median_price"]
+	if len(statistic) != len(expected) {
+		t.Fatalf("got %d statistic rows, want %d", len(statistic), len(expected))
+	}
+
+	for _, row := range statistic {
+		key := fmt.Sprintf("%s/%d", row["location"], row["rooms_count"])
+
+		want, ok := expected[key]
+		if !ok {
+			t.Errorf("unexpected statistic row %s", key)
+			continue
+		}
+
+		if got := row["price_per_meter"].(float64); math.Abs(got-want) > 1e-6 {
+			t.Errorf("%s: got median %f, want %f", key, got, want)
+		}
+	}
+}
+
+func TestCollectDelistsOnlyInsideArea(t *testing.T) {
+	server, _ := newTestServer(t, 30, ciantest.Options{})
+	storage := newMemoryStorage()
+
+	firstSeen := time.Now().Add(-48 * time.Hour)
+	storage.lifecycles[1] = offerLifecycle{OfferID: 1, Lat: 55.78, Lng: 49.12, FirstSeen: firstSeen, LastSeen: firstSeen, Status: lifecycleStatusActive}
+	storage.lifecycles[2] = offerLifecycle{OfferID: 2, Lat: 55.70, Lng: 49.30, FirstSeen: firstSeen, LastSeen: firstSeen, Status: lifecycleStatusActive}
+
+	err := collect(newTestConfig(server), collectOptions{Polygon: testArea}, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statuses := make(map[int64]string)
+	for _, row := range storage.tables["offer_lifecycle"] {
+		statuses[row["offer_id"].(int64)] = row["status"].(string)
+	}
+
+	if statuses[1] != lifecycleStatusDelisted {
+		t.Errorf("offer inside area is not delisted")
+	}
+	if _, ok := statuses[2]; ok {
+		t.Errorf("offer outside area is changed")
+	}
+}
+
+func TestCollectSavesOffers(t *testing.T) {
+	server, offers := newTestServer(t, 30, ciantest.Options{})
+	cfg := newTestConfig(server)
+	cfg.Statistic.OffersPath = filepath.Join(t.TempDir(), "offers.json")
+
+	_, err := loadOffers(cfg, "")
+	if err == nil {
+		t.Fatal("missing offers file is loaded")
+	}
+
+	err = collect(cfg, collectOptions{Polygon: testArea}, newMemoryStorage())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := loadOffers(cfg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(saved) != len(offers) {
+		t.Errorf("got %d saved offers, want %d", len(saved), len(offers))
+	}
+}
+
+func TestCollectReplay(t *testing.T) {
+	server, _ := newTestServer(t, 60, ciantest.Options{})
+	cfg := newTestConfig(server)
+	cfg.Cian.GridType = geo.GridTypeAdaptive
+	cfg.AdaptiveGrid.CoarseCellSizeMeters = 2000
+	cfg.AdaptiveGrid.TargetOffersPerCell = 20
+	cfg.AdaptiveGrid.MinCellSizeMeters = 250
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "archive.jsonl.gz")
+	outputPath := filepath.Join(dir, "rows.jsonl")
+
+	recorded := newMemoryStorage()
+	err := collect(cfg, collectOptions{Polygon: testArea, ArchivePath: archivePath}, recorded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.Close()
+
+	// replay must not touch state of live runs
+	stateDir := t.TempDir()
+	cfg.Checkpoint.Directory = filepath.Join(stateDir, "state")
+	cfg.Incremental.Enabled = true
+	cfg.Incremental.IndexPath = filepath.Join(stateDir, "offers_index.json")
+	cfg.AdaptiveGrid.DensityPath = filepath.Join(stateDir, "density.json")
+	cfg.Plan.StatsPath = filepath.Join(stateDir, "run_stats.json")
+	cfg.Statistic.OffersPath = filepath.Join(stateDir, "offers.json")
+	cfg.Photos.Enabled = true
+	cfg.Photos.Directory = filepath.Join(stateDir, "photos")
+	cfg.Photos.MaxWorkers = 1
+
+	storage, err := newJSONStorage(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = collect(cfg, collectOptions{Polygon: testArea, ReplayPath: archivePath}, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.Close()
+
+	file, err := os.Open(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	replayedCounts := make(map[string]int)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var row map[string]any
+		err := json.Unmarshal(scanner.Bytes(), &row)
+		if err != nil {
+			t.Fatal(err)
+		}
+		replayedCounts[row["table"].(string)]++
+	}
+
+	for _, table := range []string{"flat_median_price", "offer_lifecycle", "cell_statistic"} {
+		if replayedCounts[table] != len(recorded.tables[table]) {
+			t.Errorf("%s: replayed %d rows, recorded %d", table, replayedCounts[table], len(recorded.tables[table]))
+		}
+	}
+
+	entries, err := os.ReadDir(stateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		t.Errorf("replay wrote %s", entry.Name())
+	}
+}
+
+func TestCollectRetriesServerErrors(t *testing.T) {
+	server, offers := newTestServer(t, 60, ciantest.Options{ErrorEvery: 3})
+	cfg := newTestConfig(server)
+	cfg.Plan.StatsPath = filepath.Join(t.TempDir(), "run_stats.json")
+	storage := newMemoryStorage()
+
+	err := collect(cfg, collectOptions{Polygon: testArea}, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if server.Stats().Errors == 0 {
+		t.Fatal("no server errors")
+	}
+
+	stats, err := loadRunStats(cfg.Plan.StatsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Stats.Retries != server.Stats().Errors {
+		t.Errorf("got %d retries, want %d", stats.Stats.Retries, server.Stats().Errors)
+	}
+
+	if len(storage.tables["offer_lifecycle"]) != len(offers) {
+		t.Errorf("got %d offer lifecycles, want %d", len(storage.tables["offer_lifecycle"]), len(offers))
+	}
+}
+
+func TestCollectFailsOnPersistentServerErrors(t *testing.T) {
+	server, _ := newTestServer(t, 10, ciantest.Options{ErrorEvery: 1})
+
+	err := collect(newTestConfig(server), collectOptions{Polygon: testArea}, newMemoryStorage())
+	if err == nil {
+		t.Fatal("collect succeeded with failing server")
+	}
+
+	if server.Stats().Errors < 2 {
+		t.Errorf("failed request is not retried")
+	}
+}
+
+func TestCollectSlowResponses(t *testing.T) {
+	server, offers := newTestServer(t, 30, ciantest.Options{Delay: 20 * time.Millisecond})
+	storage := newMemoryStorage()
+
+	err := collect(newTestConfig(server), collectOptions{Polygon: testArea}, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(storage.tables["offer_lifecycle"]) != len(offers) {
+		t.Errorf("got %d offer lifecycles, want %d", len(storage.tables["offer_lifecycle"]), len(offers))
+	}
+}
+
+func TestCollectTruncatedClusters(t *testing.T) {
+	server, offers := newTestServer(t, 200, ciantest.Options{MaxClusterOfferIDs: 1})
+	cfg := newTestConfig(server)
+	cfg.Plan.StatsPath = filepath.Join(t.TempDir(), "run_stats.json")
+	storage := newMemoryStorage()
+
+	err := collect(cfg, collectOptions{Polygon: testArea}, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := loadRunStats(cfg.Plan.StatsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Stats.TruncatedClusters == 0 {
+		t.Error("truncated clusters are not counted")
+	}
+
+	lifecycles := len(storage.tables["offer_lifecycle"])
+	if lifecycles == 0 || lifecycles >= len(offers) {
+		t.Errorf("got %d offer lifecycles, want only offers of truncated clusters, less than %d", lifecycles, len(offers))
+	}
+}
+
+func TestCollectAdaptiveGrid(t *testing.T) {
+	server, offers := newTestServer(t, 60, ciantest.Options{})
+	cfg := newTestConfig(server)
+	cfg.Cian.GridType = geo.GridTypeAdaptive
+	cfg.AdaptiveGrid.DensityPath = filepath.Join(t.TempDir(), "density.json")
+
+	err := collect(cfg, collectOptions{Polygon: testArea}, newMemoryStorage())
+	if err == nil {
+		t.Fatal("adaptive grid without sizes is accepted")
+	}
+
+	cfg.AdaptiveGrid.CoarseCellSizeMeters = 2000
+	cfg.AdaptiveGrid.TargetOffersPerCell = 20
+	cfg.AdaptiveGrid.MinCellSizeMeters = 250
+	storage := newMemoryStorage()
+
+	err = collect(cfg, collectOptions{Polygon: testArea}, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(storage.tables["offer_lifecycle"]) != len(offers) {
+		t.Errorf("got %d offer lifecycles, want %d", len(storage.tables["offer_lifecycle"]), len(offers))
+	}
+
+	densityFiles, err := filepath.Glob(filepath.Join(filepath.Dir(cfg.AdaptiveGrid.DensityPath), "density.*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(densityFiles) != 1 {
+		t.Errorf("got density files %v after successful run, want one", densityFiles)
+	}
+}
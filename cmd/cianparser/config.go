@@ -1,23 +1,89 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/geo"
 	"gopkg.in/yaml.v2"
 )
 
 type Config struct {
 	Cian struct {
+		BaseURL                 string                        `yaml:"base_url"`
 		SearchType              string                        `yaml:"search_type"`
 		SearchQuery             map[string]cian.JSONQueryItem `yaml:"search_query"`
 		MaxCellSizeMeters       float64                       `yaml:"max_cell_size_meters"`
+		MercatorCellSize        bool                          `yaml:"mercator_cell_size"`
+		GridType                string                        `yaml:"grid_type"`
+		GeohashPrecision        int                           `yaml:"geohash_precision"`
+		H3Resolution            int                           `yaml:"h3_resolution"`
 		MaxWorkersCollectIds    int                           `yaml:"max_workers_collect_ids"`
 		MaxWorkersCollectOffers int                           `yaml:"max_workers_collect_offers"`
+		MaxRequestsPerSecond    float64                       `yaml:"max_requests_per_second"`
 	} `yaml:"cian"`
+	Area struct {
+		BufferMeters   float64  `yaml:"buffer_meters"`
+		SimplifyMeters float64  `yaml:"simplify_meters"`
+		Exclusions     []string `yaml:"exclusions"`
+	} `yaml:"area"`
+	AdaptiveGrid struct {
+		DensityPath          string  `yaml:"density_path"`
+		TargetOffersPerCell  int     `yaml:"target_offers_per_cell"`
+		MinCellSizeMeters    float64 `yaml:"min_cell_size_meters"`
+		CoarseCellSizeMeters float64 `yaml:"coarse_cell_size_meters"`
+	} `yaml:"adaptive_grid"`
+	Plan struct {
+		StatsPath      string  `yaml:"stats_path"`
+		RequestSeconds float64 `yaml:"request_seconds"`
+		CaptchaPrice   float64 `yaml:"captcha_price"`
+	} `yaml:"plan"`
+	Checkpoint struct {
+		Directory string `yaml:"directory"`
+	} `yaml:"checkpoint"`
+	Incremental struct {
+		Enabled    bool          `yaml:"enabled"`
+		IndexPath  string        `yaml:"index_path"`
+		RefreshAge time.Duration `yaml:"refresh_age"`
+	} `yaml:"incremental"`
+	Statistic struct {
+		GroupBySellerType bool   `yaml:"group_by_seller_type"`
+		TopAgenciesCount  int    `yaml:"top_agencies_count"`
+		KeepDuplicates    bool   `yaml:"keep_duplicates"`
+		OutsidePolygon    string `yaml:"outside_polygon"`
+		OffersPath        string `yaml:"offers_path"`
+
+		LocationSource       string `yaml:"location_source"`
+		LocationPolygonsPath string `yaml:"location_polygons_path"`
+		LocationProperty     string `yaml:"location_property"`
+	} `yaml:"statistic"`
+	Proximity struct {
+		Layers []struct {
+			Name         string `yaml:"name"`
+			Path         string `yaml:"path"`
+			NameProperty string `yaml:"name_property"`
+		} `yaml:"layers"`
+		BucketLayer string    `yaml:"bucket_layer"`
+		Buckets     []float64 `yaml:"buckets"`
+	} `yaml:"proximity"`
+	Dedup struct {
+		MaxDistanceMeters float64 `yaml:"max_distance_meters"`
+		MaxAreaDiff       float64 `yaml:"max_area_diff"`
+		MaxPriceDiff      float64 `yaml:"max_price_diff"`
+	} `yaml:"dedup"`
+	Photos struct {
+		Enabled    bool   `yaml:"enabled"`
+		Directory  string `yaml:"directory"`
+		MaxWorkers int    `yaml:"max_workers"`
+	} `yaml:"photos"`
 	Rucaptcha struct {
-		APIKey string `yaml:"api_key"`
+		APIKey          string `yaml:"api_key"`
+		BaseURL         string `yaml:"base_url"`
+		PollingInterval int    `yaml:"polling_interval"`
 	} `yaml:"rucaptcha"`
 	Database struct {
 		Address  string `yaml:"address"`
@@ -27,6 +93,38 @@ type Config struct {
 	} `yaml:"database"`
 }
 
+// getGrid returns search grid, rect grid is used by default
+func getGrid(cfg *Config) geo.Grid {
+	gridType := cfg.Cian.GridType
+	if gridType == "" {
+		gridType = geo.GridTypeRect
+	}
+
+	return geo.Grid{
+		Type:             gridType,
+		CellSize:         cfg.Cian.MaxCellSizeMeters,
+		MercatorCellSize: cfg.Cian.MercatorCellSize,
+		GeohashPrecision: cfg.Cian.GeohashPrecision,
+		H3Resolution:     cfg.Cian.H3Resolution,
+		TargetOffers:     cfg.AdaptiveGrid.TargetOffersPerCell,
+		MinCellSize:      cfg.AdaptiveGrid.MinCellSizeMeters,
+	}
+}
+
+// getConfigHash returns hash of config and polygon which checkpoint was made with
+func getConfigHash(configPath string, polygon []byte) (string, error) {
+	configData, err := os.ReadFile(configPath)
+	if err != nil {
+		return "", fmt.Errorf("can't read config file: %w", err)
+	}
+
+	hash := sha256.New()
+	hash.Write(configData)
+	hash.Write(polygon)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
 func newConfig(configPath string) (*Config, error) {
 	config := &Config{}
 
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"time"
+)
+
+// saveDuplicateGroups writes only offers which have duplicates
+func saveDuplicateGroups(storage Storage, timestamp time.Time, groups map[int64]int64) error {
+	groupSizes := make(map[int64]int)
+	for _, groupID := range groups {
+		groupSizes[groupID]++
+	}
+
+	rows := make([][]any, 0)
+	for offerID, groupID := range groups {
+		if groupSizes[groupID] < 2 {
+			continue
+		}
+
+		rows = append(rows, []any{timestamp.UTC(), offerID, groupID})
+	}
+
+	return storage.Insert("offer_duplicate_group", []string{"date_time", "offer_id", "group_id"}, rows)
+}
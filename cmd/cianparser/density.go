@@ -0,0 +1,132 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/geo"
+	"github.com/twpayne/go-geos"
+)
+
+// getDensityFromCells returns cluster sizes as density map
+func getDensityFromCells(cells []cian.CellClusters) []geo.DensityPoint {
+	density := make([]geo.DensityPoint, 0)
+	for _, cell := range cells {
+		for _, cluster := range cell.Clusters {
+			density = append(density, geo.DensityPoint{
+				Lng:   cluster.Coordinates.Lng,
+				Lat:   cluster.Coordinates.Lat,
+				Count: cluster.Count,
+			})
+		}
+	}
+
+	return density
+}
+
+// getDensityPath returns density map path keyed by polygon hash, so density maps
+// of different areas don't replace each other
+func getDensityPath(path string, polygon *geos.Geom) string {
+	hash := sha256.Sum256(polygon.ToWKB())
+	ext := filepath.Ext(path)
+
+	return fmt.Sprintf("%s.%s%s", strings.TrimSuffix(path, ext), hex.EncodeToString(hash[:])[:16], ext)
+}
+
+// loadDensity returns nil if there is no density map from previous run
+func loadDensity(path string) ([]geo.DensityPoint, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't read density map: %w", err)
+	}
+
+	var density []geo.DensityPoint
+	err = json.Unmarshal(data, &density)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse density map: %w", err)
+	}
+
+	return density, nil
+}
+
+func saveDensity(path string, density []geo.DensityPoint) error {
+	data, err := json.Marshal(density)
+	if err != nil {
+		return fmt.Errorf("can't marshal density map: %w", err)
+	}
+
+	err = os.WriteFile(path, data, 0o644)
+	if err != nil {
+		return fmt.Errorf("can't write density map: %w", err)
+	}
+
+	return nil
+}
+
+func validateAdaptiveGrid(cfg *Config) error {
+	if cfg.AdaptiveGrid.CoarseCellSizeMeters <= 0 {
+		return errors.New("coarse cell size must be positive")
+	}
+
+	if cfg.AdaptiveGrid.MinCellSizeMeters <= 0 {
+		return errors.New("min cell size must be positive")
+	}
+
+	if cfg.AdaptiveGrid.TargetOffersPerCell <= 0 {
+		return errors.New("target offers per cell must be positive")
+	}
+
+	return nil
+}
+
+// getSearchGrid returns grid from config, adaptive grid gets density map of previous run
+// in the same polygon or of coarse pass made by parser, parser can be nil if requests are not allowed
+func getSearchGrid(cfg *Config, polygon *geos.Geom, parser *cian.Parser) (geo.Grid, error) {
+	grid := getGrid(cfg)
+	if grid.Type != geo.GridTypeAdaptive {
+		return grid, nil
+	}
+
+	err := validateAdaptiveGrid(cfg)
+	if err != nil {
+		return geo.Grid{}, fmt.Errorf("invalid adaptive grid config: %w", err)
+	}
+
+	var density []geo.DensityPoint
+	if cfg.AdaptiveGrid.DensityPath != "" {
+		density, err = loadDensity(getDensityPath(cfg.AdaptiveGrid.DensityPath, polygon))
+		if err != nil {
+			return geo.Grid{}, err
+		}
+	}
+
+	if density == nil {
+		if parser == nil {
+			return geo.Grid{}, fmt.Errorf("no density map from previous run")
+		}
+
+		log.Printf("no density map from previous run, making coarse pass")
+
+		parser.SetGrid(geo.Grid{Type: geo.GridTypeRect, CellSize: cfg.AdaptiveGrid.CoarseCellSizeMeters})
+		cells, err := parser.GetClusters()
+		if err != nil {
+			return geo.Grid{}, fmt.Errorf("can't make coarse pass: %w", err)
+		}
+
+		density = getDensityFromCells(cells)
+	}
+
+	grid.Density = density
+	return grid, nil
+}
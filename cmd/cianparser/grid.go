@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/geo"
+)
+
+// matchCellClusters returns clusters of every grid cell matched by cell bounds,
+// it is nil for cells which weren't requested
+func matchCellClusters(cells []geo.Cell, cellClusters []cian.CellClusters) []*cian.CellClusters {
+	clustersByBounds := make(map[cian.Bounds]*cian.CellClusters, len(cellClusters))
+	for i := range cellClusters {
+		clustersByBounds[cellClusters[i].Bounds] = &cellClusters[i]
+	}
+
+	matched := make([]*cian.CellClusters, len(cells))
+	for i, cell := range cells {
+		matched[i] = clustersByBounds[cian.GeosBoundsToCianBounds(cell.Bounds)]
+	}
+
+	return matched
+}
+
+// writeGrid writes search grid as GeoJSON, cells with clusters get cluster and offer counts
+func writeGrid(path string, cells []geo.Cell, cellClusters []cian.CellClusters) error {
+	fc := geo.CellsToFeatureCollection(cells)
+
+	for i, cell := range matchCellClusters(cells, cellClusters) {
+		if cell == nil {
+			continue
+		}
+
+		offersCount := 0
+		offerIDsCount := 0
+		for _, cluster := range cell.Clusters {
+			offersCount += cluster.Count
+			offerIDsCount += len(cluster.ClusterOfferIds)
+		}
+
+		properties := fc.Features[i].Properties
+		properties["clusters"] = len(cell.Clusters)
+		properties["offers"] = offersCount
+		properties["offer_ids"] = offerIDsCount
+	}
+
+	fcJSON, err := json.Marshal(fc)
+	if err != nil {
+		return fmt.Errorf("can't marshal grid: %w", err)
+	}
+
+	err = os.WriteFile(path, fcJSON, 0o644)
+	if err != nil {
+		return fmt.Errorf("can't write grid: %w", err)
+	}
+
+	return nil
+}
+
+func runGrid(args []string) int {
+	flags := flag.NewFlagSet("grid", flag.ExitOnError)
+
+	var configFilePath string
+	flags.StringVar(&configFilePath, "c", "config.yaml", "config file path")
+
+	var geojsonFilePath string
+	flags.StringVar(&geojsonFilePath, "f", "polygon.geojson", polygonFlagUsage)
+
+	var outputFilePath string
+	flags.StringVar(&outputFilePath, "o", "grid.geojson", "output geojson file path")
+
+	flags.Parse(args)
+
+	cfg, err := newConfig(configFilePath)
+	if err != nil {
+		log.Printf("can't read config: %s", err)
+		return 1
+	}
+
+	polygon, err := readPolygon(geojsonFilePath, cfg)
+	if err != nil {
+		log.Printf("can't read polygon: %s", err)
+		return 1
+	}
+
+	grid, err := getSearchGrid(cfg, polygon, nil)
+	if err != nil {
+		log.Printf("can't get search grid: %s", err)
+		return 1
+	}
+
+	cells, err := geo.GetGridCells(polygon, grid)
+	if err != nil {
+		log.Printf("can't get cells: %s", err)
+		return 1
+	}
+
+	err = writeGrid(outputFilePath, cells, nil)
+	if err != nil {
+		log.Printf("can't write grid: %s", err)
+		return 1
+	}
+
+	log.Printf("%d cells written to %s", len(cells), outputFilePath)
+	return 0
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/geo"
+	"github.com/twpayne/go-geos"
+)
+
+// getHeatmapPoints returns price per meter of offers
+func getHeatmapPoints(offers []cian.Offer) []geo.HeatmapPoint {
+	points := make([]geo.HeatmapPoint, 0, len(offers))
+	for _, offer := range offers {
+		pricePerMeter, err := offer.PricePerMeter()
+		if err != nil {
+			continue
+		}
+
+		points = append(points, geo.HeatmapPoint{
+			Lng:   offer.Geo.Coordinates.Lng,
+			Lat:   offer.Geo.Coordinates.Lat,
+			Value: pricePerMeter,
+		})
+	}
+
+	return points
+}
+
+func writeHeatmapRaster(path string, polygon *geos.Geom, sizeMeters float64, points []geo.HeatmapPoint) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("can't create raster file: %w", err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	err = geo.WriteHeatmapASCIIGrid(writer, polygon, sizeMeters, points)
+	if err != nil {
+		return err
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		return fmt.Errorf("can't write raster file: %w", err)
+	}
+
+	err = file.Close()
+	if err != nil {
+		return fmt.Errorf("can't write raster file: %w", err)
+	}
+
+	// ASCII grid has no projection, GIS tools read it from .prj file with the same name
+	prjPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".prj"
+	err = os.WriteFile(prjPath, []byte(geo.RasterProjectionWKT), 0o644)
+	if err != nil {
+		return fmt.Errorf("can't write raster projection file: %w", err)
+	}
+
+	return nil
+}
+
+func runHeatmap(args []string) int {
+	flags := flag.NewFlagSet("heatmap", flag.ExitOnError)
+
+	var configFilePath string
+	flags.StringVar(&configFilePath, "c", "config.yaml", "config file path")
+
+	var geojsonFilePath string
+	flags.StringVar(&geojsonFilePath, "f", "polygon.geojson", polygonFlagUsage)
+
+	var offersFilePath string
+	flags.StringVar(&offersFilePath, "i", "", "offers file path, statistic.offers_path from config by default")
+
+	var shape string
+	flags.StringVar(&shape, "shape", geo.HeatmapShapeRect, "cell shape: rect or hex")
+
+	var sizeMeters float64
+	flags.Float64Var(&sizeMeters, "size", 1000, "cell size in meters")
+
+	var outputFilePath string
+	flags.StringVar(&outputFilePath, "o", "heatmap.geojson", "output geojson file path")
+
+	var rasterFilePath string
+	flags.StringVar(&rasterFilePath, "raster", "", "write ESRI ASCII grid in EPSG:3857 to file, projection is written to .prj file next to it")
+
+	flags.Parse(args)
+
+	cfg, err := newConfig(configFilePath)
+	if err != nil {
+		log.Printf("can't read config: %s", err)
+		return 1
+	}
+
+	polygon, err := readPolygon(geojsonFilePath, cfg)
+	if err != nil {
+		log.Printf("can't read polygon: %s", err)
+		return 1
+	}
+
+	offers, err := loadOffers(cfg, offersFilePath)
+	if err != nil {
+		log.Printf("can't load offers: %s", err)
+		return 1
+	}
+
+	points := getHeatmapPoints(offers)
+	if len(points) == 0 {
+		log.Printf("no offers with price per meter")
+		return 1
+	}
+
+	cells, err := geo.BuildHeatmap(polygon, shape, sizeMeters, points)
+	if err != nil {
+		log.Printf("can't build heatmap: %s", err)
+		return 1
+	}
+
+	fcJSON, err := json.Marshal(geo.HeatmapToFeatureCollection(cells))
+	if err != nil {
+		log.Printf("can't marshal heatmap: %s", err)
+		return 1
+	}
+
+	err = os.WriteFile(outputFilePath, fcJSON, 0o644)
+	if err != nil {
+		log.Printf("can't write heatmap: %s", err)
+		return 1
+	}
+
+	log.Printf("%d heatmap cells written to %s", len(cells), outputFilePath)
+
+	if rasterFilePath != "" {
+		err = writeHeatmapRaster(rasterFilePath, polygon, sizeMeters, points)
+		if err != nil {
+			log.Printf("can't write heatmap raster: %s", err)
+			return 1
+		}
+
+		log.Printf("heatmap raster written to %s", rasterFilePath)
+	}
+
+	return 0
+}
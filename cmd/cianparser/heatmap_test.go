@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mishannn/cianparser-go/internal/geo"
+)
+
+func TestWriteHeatmapRasterProjection(t *testing.T) {
+	geojson, err := geo.ReadArea(testArea, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	polygon, _, err := geo.NormalizeGeoJSON(geojson)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	err = writeHeatmapRaster(filepath.Join(dir, "heatmap.asc"), polygon, 1000, []geo.HeatmapPoint{{Lng: 49.12, Lat: 55.78, Value: 100}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prj, err := os.ReadFile(filepath.Join(dir, "heatmap.prj"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(prj) != geo.RasterProjectionWKT {
+		t.Errorf("got projection %s", prj)
+	}
+}
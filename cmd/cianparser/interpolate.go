@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mishannn/cianparser-go/internal/geo"
+	"github.com/mishannn/cianparser-go/internal/interpolation"
+	"github.com/twpayne/go-geos"
+)
+
+const (
+	interpolationIDW     = "idw"
+	interpolationKriging = "kriging"
+)
+
+func getInterpolationBuilder(method string, power float64, neighbors int) (interpolation.Builder, error) {
+	switch method {
+	case interpolationIDW:
+		return func(samples []interpolation.Sample) (interpolation.Interpolator, error) {
+			return interpolation.NewIDW(samples, power, neighbors)
+		}, nil
+	case interpolationKriging:
+		return func(samples []interpolation.Sample) (interpolation.Interpolator, error) {
+			return interpolation.NewOrdinaryKriging(samples, neighbors)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown interpolation method: %s", method)
+	}
+}
+
+func parsePoint(value string) (float64, float64, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("point must be lng,lat")
+	}
+
+	lng, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("can't parse longitude: %w", err)
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("can't parse latitude: %w", err)
+	}
+
+	return lng, lat, nil
+}
+
+func writeInterpolatedNodes(path string, polygon *geos.Geom, sizeMeters float64, interpolator interpolation.Interpolator) (int, error) {
+	nodes, err := geo.GetGridNodes(polygon, sizeMeters)
+	if err != nil {
+		return 0, fmt.Errorf("can't get grid nodes: %w", err)
+	}
+
+	fc := geo.NewFeatureCollection()
+	for _, node := range nodes {
+		value, err := interpolator.Predict(node[0], node[1])
+		if err != nil {
+			return 0, fmt.Errorf("can't predict node value: %w", err)
+		}
+
+		fc.AddPoint(node[0], node[1], map[string]any{
+			"value": value,
+		})
+	}
+
+	fcJSON, err := json.Marshal(fc)
+	if err != nil {
+		return 0, fmt.Errorf("can't marshal nodes: %w", err)
+	}
+
+	err = os.WriteFile(path, fcJSON, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("can't write nodes: %w", err)
+	}
+
+	return len(nodes), nil
+}
+
+func runInterpolate(args []string) int {
+	flags := flag.NewFlagSet("interpolate", flag.ExitOnError)
+
+	var configFilePath string
+	flags.StringVar(&configFilePath, "c", "config.yaml", "config file path")
+
+	var geojsonFilePath string
+	flags.StringVar(&geojsonFilePath, "f", "polygon.geojson", polygonFlagUsage)
+
+	var offersFilePath string
+	flags.StringVar(&offersFilePath, "i", "", "offers file path, statistic.offers_path from config by default")
+
+	var method string
+	flags.StringVar(&method, "method", interpolationIDW, "interpolation method: idw or kriging")
+
+	var power float64
+	flags.Float64Var(&power, "power", 2, "idw distance power")
+
+	var neighbors int
+	flags.IntVar(&neighbors, "neighbors", 16, "number of nearest offers used for estimate")
+
+	var folds int
+	flags.IntVar(&folds, "folds", 5, "cross-validation folds, 0 to skip")
+
+	var point string
+	flags.StringVar(&point, "point", "", "estimate price per meter at lng,lat")
+
+	var totalArea float64
+	flags.Float64Var(&totalArea, "area", 0, "flat total area in square meters to estimate fair price at point")
+
+	var sizeMeters float64
+	flags.Float64Var(&sizeMeters, "size", 1000, "distance between grid nodes in meters")
+
+	var outputFilePath string
+	flags.StringVar(&outputFilePath, "o", "", "write estimates at grid nodes to geojson file")
+
+	flags.Parse(args)
+
+	cfg, err := newConfig(configFilePath)
+	if err != nil {
+		log.Printf("can't read config: %s", err)
+		return 1
+	}
+
+	build, err := getInterpolationBuilder(method, power, neighbors)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+
+	offers, err := loadOffers(cfg, offersFilePath)
+	if err != nil {
+		log.Printf("can't load offers: %s", err)
+		return 1
+	}
+
+	points := getHeatmapPoints(offers)
+	samples := make([]interpolation.Sample, len(points))
+	for i, p := range points {
+		samples[i] = interpolation.Sample{Lng: p.Lng, Lat: p.Lat, Value: p.Value}
+	}
+
+	if folds > 0 {
+		validation, err := interpolation.CrossValidate(samples, folds, build)
+		if err != nil {
+			log.Printf("can't cross-validate: %s", err)
+			return 1
+		}
+
+		fmt.Printf("cross-validation: %d offers, %d folds, rmse %.0f, mae %.0f\n", validation.Count, folds, validation.RMSE, validation.MAE)
+	}
+
+	interpolator, err := build(samples)
+	if err != nil {
+		log.Printf("can't build interpolator: %s", err)
+		return 1
+	}
+
+	if point != "" {
+		lng, lat, err := parsePoint(point)
+		if err != nil {
+			log.Printf("can't parse point: %s", err)
+			return 1
+		}
+
+		pricePerMeter, err := interpolator.Predict(lng, lat)
+		if err != nil {
+			log.Printf("can't estimate price: %s", err)
+			return 1
+		}
+
+		fmt.Printf("price per meter: %.0f\n", pricePerMeter)
+		if totalArea > 0 {
+			fmt.Printf("fair price: %.0f\n", pricePerMeter*totalArea)
+		}
+	}
+
+	if outputFilePath != "" {
+		polygon, err := readPolygon(geojsonFilePath, cfg)
+		if err != nil {
+			log.Printf("can't read polygon: %s", err)
+			return 1
+		}
+
+		count, err := writeInterpolatedNodes(outputFilePath, polygon, sizeMeters, interpolator)
+		if err != nil {
+			log.Printf("can't write interpolated nodes: %s", err)
+			return 1
+		}
+
+		log.Printf("%d nodes written to %s", count, outputFilePath)
+	}
+
+	return 0
+}
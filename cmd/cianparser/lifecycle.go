@@ -0,0 +1,136 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/geo"
+)
+
+const (
+	lifecycleStatusActive   = "active"
+	lifecycleStatusDelisted = "delisted"
+	lifecycleStatusRelisted = "relisted"
+)
+
+type offerLifecycle struct {
+	OfferID    int64
+	Location   string
+	Category   string
+	RoomsCount int
+	Lat        float64
+	Lng        float64
+	FirstSeen  time.Time
+	LastSeen   time.Time
+	Status     string
+	FirstPrice float64
+	LastPrice  float64
+}
+
+func loadOfferLifecycles(db *sql.DB) (map[int64]offerLifecycle, error) {
+	rows, err := db.Query("SELECT offer_id, location, category, rooms_count, lat, lng, first_seen, last_seen, status, first_price, last_price FROM offer_lifecycle FINAL")
+	if err != nil {
+		return nil, fmt.Errorf("can't query offer lifecycles: %w", err)
+	}
+	defer rows.Close()
+
+	lifecycles := make(map[int64]offerLifecycle)
+	for rows.Next() {
+		var offerID uint64
+		var roomsCount uint8
+		var lifecycle offerLifecycle
+
+		err := rows.Scan(&offerID, &lifecycle.Location, &lifecycle.Category, &roomsCount, &lifecycle.Lat, &lifecycle.Lng, &lifecycle.FirstSeen, &lifecycle.LastSeen, &lifecycle.Status, &lifecycle.FirstPrice, &lifecycle.LastPrice)
+		if err != nil {
+			return nil, fmt.Errorf("can't scan offer lifecycle row: %w", err)
+		}
+
+		lifecycle.OfferID = int64(offerID)
+		lifecycle.RoomsCount = int(roomsCount)
+		lifecycles[lifecycle.OfferID] = lifecycle
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("can't read offer lifecycles: %w", err)
+	}
+
+	return lifecycles, nil
+}
+
+// updateOfferLifecycles applies current run offers to lifecycles and returns changed lifecycles,
+// unseen lifecycles are delisted only when delist is set and their last coordinates are inside area
+func updateOfferLifecycles(lifecycles map[int64]offerLifecycle, offers []cian.Offer, area *geo.Area, delist bool, timestamp time.Time) []offerLifecycle {
+	changed := make([]offerLifecycle, 0, len(offers))
+	seen := make(map[int64]struct{}, len(offers))
+
+	for _, offer := range offers {
+		seen[offer.CianID] = struct{}{}
+
+		lifecycle, ok := lifecycles[offer.CianID]
+		if !ok {
+			lifecycle = offerLifecycle{
+				OfferID:    offer.CianID,
+				FirstSeen:  timestamp,
+				Status:     lifecycleStatusActive,
+				FirstPrice: offer.BargainTerms.PriceRur,
+			}
+		} else if lifecycle.Status == lifecycleStatusDelisted {
+			lifecycle.Status = lifecycleStatusRelisted
+		} else {
+			// relisted status is kept only for the run which saw offer again
+			lifecycle.Status = lifecycleStatusActive
+		}
+
+		lifecycle.Location = offer.Location
+		lifecycle.Category = offer.Category
+		lifecycle.RoomsCount = offer.RoomsCount
+		lifecycle.Lat = offer.Geo.Coordinates.Lat
+		lifecycle.Lng = offer.Geo.Coordinates.Lng
+		lifecycle.LastSeen = timestamp
+		lifecycle.LastPrice = offer.BargainTerms.PriceRur
+
+		lifecycles[offer.CianID] = lifecycle
+		changed = append(changed, lifecycle)
+	}
+
+	if !delist {
+		return changed
+	}
+
+	for id, lifecycle := range lifecycles {
+		if _, ok := seen[id]; ok || lifecycle.Status == lifecycleStatusDelisted {
+			continue
+		}
+
+		if !area.Contains(lifecycle.Lng, lifecycle.Lat) {
+			continue
+		}
+
+		lifecycle.Status = lifecycleStatusDelisted
+		lifecycles[id] = lifecycle
+		changed = append(changed, lifecycle)
+	}
+
+	return changed
+}
+
+// getDaysOnMarket returns days between first and last seen time for every lifecycle
+func getDaysOnMarket(lifecycles map[int64]offerLifecycle) map[int64]float64 {
+	daysOnMarket := make(map[int64]float64, len(lifecycles))
+	for id, lifecycle := range lifecycles {
+		daysOnMarket[id] = lifecycle.LastSeen.Sub(lifecycle.FirstSeen).Hours() / 24
+	}
+
+	return daysOnMarket
+}
+
+func saveOfferLifecycles(storage Storage, timestamp time.Time, lifecycles []offerLifecycle) error {
+	rows := make([][]any, 0)
+	for _, row := range lifecycles {
+		rows = append(rows, []any{timestamp.UTC(), row.OfferID, row.Location, row.Category, row.RoomsCount, row.Lat, row.Lng, row.FirstSeen.UTC(), row.LastSeen.UTC(), row.Status, row.FirstPrice, row.LastPrice})
+	}
+
+	return storage.Insert("offer_lifecycle", []string{"updated_at", "offer_id", "location", "category", "rooms_count", "lat", "lng", "first_seen", "last_seen", "status", "first_price", "last_price"}, rows)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/geo"
+)
+
+func TestUpdateOfferLifecyclesRelisting(t *testing.T) {
+	geojson, err := geo.ReadArea(testArea, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	polygon, _, err := geo.NormalizeGeoJSON(geojson)
+	if err != nil {
+		t.Fatal(err)
+	}
+	area := geo.NewArea(polygon)
+
+	offer := cian.Offer{
+		CianID:       1,
+		Geo:          cian.Geo{Coordinates: cian.Coordinates{Lat: 55.78, Lng: 49.12}},
+		BargainTerms: cian.BargainTerms{PriceRur: 5000000},
+	}
+
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	lifecycles := make(map[int64]offerLifecycle)
+
+	runs := []struct {
+		offers []cian.Offer
+		status string
+	}{
+		{offers: []cian.Offer{offer}, status: lifecycleStatusActive},
+		{offers: nil, status: lifecycleStatusDelisted},
+		{offers: []cian.Offer{offer}, status: lifecycleStatusRelisted},
+		{offers: []cian.Offer{offer}, status: lifecycleStatusActive},
+	}
+
+	for i, run := range runs {
+		updateOfferLifecycles(lifecycles, run.offers, area, true, start.AddDate(0, 0, i))
+
+		if status := lifecycles[1].Status; status != run.status {
+			t.Errorf("run %d: got status %s, want %s", i, status, run.status)
+		}
+	}
+
+	if !lifecycles[1].FirstSeen.Equal(start) {
+		t.Errorf("got first seen %s, want %s", lifecycles[1].FirstSeen, start)
+	}
+
+	if days := getDaysOnMarket(lifecycles)[1]; days != 3 {
+		t.Errorf("got %f days on market, want 3", days)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	cianparser "github.com/mishannn/cianparser-go"
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/geo"
+)
+
+const (
+	locationSourceAddress = "address"
+	locationSourcePolygon = "polygon"
+)
+
+// locationUnknown is a location of offers outside of districts, so they don't mix with named ones
+const locationUnknown = "unknown"
+
+func getDistrictString(addresses []cian.Address) string {
+	parts := make([]string, 0)
+
+	for _, address := range addresses {
+		if address.GeoType == "location" || address.GeoType == "district" {
+			parts = append(parts, address.FullName)
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// setOfferLocations fills offers location from address or from districts when they are set,
+// offers outside of all districts get unknown location and are returned
+func setOfferLocations(offers []cian.Offer, districts *geo.DistrictIndex) []cian.Offer {
+	unmatched := make([]cian.Offer, 0)
+
+	for i := range offers {
+		offer := &offers[i]
+
+		if districts == nil {
+			offer.Location = getDistrictString(offer.Geo.Address)
+		} else {
+			location, ok := districts.Locate(offer.Geo.Coordinates.Lng, offer.Geo.Coordinates.Lat)
+			if !ok {
+				unmatched = append(unmatched, *offer)
+			}
+
+			offer.Location = location
+			if offer.Location == "" {
+				offer.Location = locationUnknown
+			}
+		}
+	}
+
+	return unmatched
+}
+
+func saveUnmatchedOffers(storage Storage, timestamp time.Time, offers []cian.Offer) error {
+	rows := make([][]any, 0)
+	for _, offer := range offers {
+		rows = append(rows, []any{timestamp.UTC(), offer.CianID, offer.Geo.Coordinates.Lat, offer.Geo.Coordinates.Lng, getDistrictString(offer.Geo.Address)})
+	}
+
+	return storage.Insert("offer_unmatched_location", []string{"date_time", "offer_id", "lat", "lng", "address"}, rows)
+}
+
+// getDistrictIndex returns nil when locations are taken from address, districts are read
+// from target area input when polygons path is not set
+func getDistrictIndex(cfg *Config, input string) (*geo.DistrictIndex, error) {
+	switch cfg.Statistic.LocationSource {
+	case "", locationSourceAddress:
+		return nil, nil
+	case locationSourcePolygon:
+	default:
+		return nil, fmt.Errorf("unknown location source: %s", cfg.Statistic.LocationSource)
+	}
+
+	if cfg.Statistic.LocationPolygonsPath != "" {
+		geojson, err := os.ReadFile(cfg.Statistic.LocationPolygonsPath)
+		if err != nil {
+			return nil, fmt.Errorf("can't read location polygons file: %w", err)
+		}
+
+		return geo.NewDistrictIndex(string(geojson), cfg.Statistic.LocationProperty)
+	}
+
+	geojson, err := geo.ReadArea(input, cianparser.Presets)
+	if err != nil {
+		return nil, fmt.Errorf("can't read area: %w", err)
+	}
+
+	return geo.NewDistrictIndex(geojson, cfg.Statistic.LocationProperty)
+}
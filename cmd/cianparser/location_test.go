@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/geo"
+)
+
+func TestSetOfferLocationsUnknown(t *testing.T) {
+	districts, err := geo.NewDistrictIndex(`{"type":"FeatureCollection","features":[{"type":"Feature","properties":{"name":"Вахитовский"},"geometry":{"type":"Polygon","coordinates":[[[49.10,55.78],[49.12,55.78],[49.12,55.80],[49.10,55.80],[49.10,55.78]]]}}]}`, "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offers := []cian.Offer{
+		{CianID: 1, Geo: cian.Geo{Coordinates: cian.Coordinates{Lat: 55.79, Lng: 49.11}}},
+		{CianID: 2, Geo: cian.Geo{Coordinates: cian.Coordinates{Lat: 55.79, Lng: 49.20}}},
+	}
+
+	unmatched := setOfferLocations(offers, districts)
+
+	if offers[0].Location != "Вахитовский" {
+		t.Errorf("got location %q, want district name", offers[0].Location)
+	}
+	if offers[1].Location != locationUnknown {
+		t.Errorf("got location %q for offer outside of districts, want %q", offers[1].Location, locationUnknown)
+	}
+	if len(unmatched) != 1 || unmatched[0].CianID != 2 {
+		t.Errorf("got unmatched offers %v, want offer 2", unmatched)
+	}
+}
+
+func TestSetOfferLocationsAddressKeepsEmpty(t *testing.T) {
+	offers := []cian.Offer{
+		{CianID: 1, Geo: cian.Geo{Address: []cian.Address{{FullName: "Казань", GeoType: "location"}}}},
+		{CianID: 2},
+	}
+
+	unmatched := setOfferLocations(offers, nil)
+
+	if offers[0].Location != "Казань" {
+		t.Errorf("got location %q, want address location", offers[0].Location)
+	}
+	if offers[1].Location != "" {
+		t.Errorf("got location %q for offer without address, want empty", offers[1].Location)
+	}
+	if len(unmatched) != 0 {
+		t.Errorf("got unmatched offers %v in address mode", unmatched)
+	}
+}
@@ -3,16 +3,23 @@ package main
 import (
 	"database/sql"
 	"embed"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"net/http/cookiejar"
 	"os"
+	"strings"
 	"time"
 
-	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/mishannn/cianparser-go/internal/archive"
+	"github.com/mishannn/cianparser-go/internal/checkpoint"
 	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/dedup"
+	"github.com/mishannn/cianparser-go/internal/geo"
+	"github.com/mishannn/cianparser-go/internal/incremental"
+	"github.com/mishannn/cianparser-go/internal/photos"
 	"github.com/pressly/goose/v3"
 )
 
@@ -33,16 +40,12 @@ func upMigrations(db *sql.DB) error {
 	return nil
 }
 
-func newHttpClient() *http.Client {
+func newHttpClient(transport http.RoundTripper) *http.Client {
 	jar, err := cookiejar.New(nil)
 	if err != nil {
 		log.Printf("can't create cookie jar: %s", err)
 	}
 
-	transport := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-	}
-
 	return &http.Client{
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
@@ -52,68 +55,426 @@ func newHttpClient() *http.Client {
 	}
 }
 
-func runApplication() int {
-	var configFilePath string
-	flag.StringVar(&configFilePath, "c", "config.yaml", "config file path")
+// getOffers fetches offers, only changed offers are fetched if useIndex is set
+func getOffers(parser *cian.Parser, cfg *Config, cells []cian.CellClusters, offerIDs []int64, useIndex bool) ([]cian.Offer, error) {
+	if !useIndex {
+		return parser.GetOffers(offerIDs)
+	}
 
-	var geojsonFilePath string
-	flag.StringVar(&geojsonFilePath, "f", "polygon.geojson", "geojson file path")
+	index, err := incremental.Load(cfg.Incremental.IndexPath)
+	if err != nil {
+		return nil, fmt.Errorf("can't load offers index: %w", err)
+	}
+
+	now := time.Now()
+	prices := cian.GetClusterPricesFromCells(cells)
 
-	flag.Parse()
+	idsToFetch := index.GetIDsToFetch(offerIDs, prices, now, cfg.Incremental.RefreshAge)
+	log.Printf("fetching %d of %d offers", len(idsToFetch), len(offerIDs))
 
-	cfg, err := newConfig(configFilePath)
+	offers, err := parser.GetOffers(idsToFetch)
+	if err != nil {
+		return nil, err
+	}
+
+	index.Update(offers, prices, now)
+	offers = index.GetOffers(offerIDs)
+
+	err = index.Save()
+	if err != nil {
+		return nil, fmt.Errorf("can't save offers index: %w", err)
+	}
+
+	return offers, nil
+}
+
+type collectOptions struct {
+	ConfigPath  string
+	Polygon     string
+	Resume      bool
+	ArchivePath string
+	ReplayPath  string
+	OutputPath  string
+	GridPath    string
+}
+
+func runCollect(args []string) int {
+	flags := flag.NewFlagSet("collect", flag.ExitOnError)
+
+	var opts collectOptions
+	flags.StringVar(&opts.ConfigPath, "c", "config.yaml", "config file path")
+	flags.StringVar(&opts.Polygon, "f", "polygon.geojson", polygonFlagUsage)
+	flags.BoolVar(&opts.Resume, "resume", false, "continue interrupted run from checkpoint")
+	flags.StringVar(&opts.ArchivePath, "archive", "", "write requests and responses to gzipped jsonl archive")
+	flags.StringVar(&opts.ReplayPath, "replay", "", "serve responses from archive instead of network, rows are written as jsonl instead of database")
+	flags.StringVar(&opts.OutputPath, "o", "", "jsonl file for rows of replayed run, stdout by default")
+	flags.StringVar(&opts.GridPath, "grid", "", "write search grid with cluster and offer counts to geojson file")
+
+	flags.Parse(args)
+
+	cfg, err := newConfig(opts.ConfigPath)
 	if err != nil {
 		log.Printf("can't read config: %s", err)
 		return 1
 	}
 
-	db := clickhouse.OpenDB(&clickhouse.Options{
-		Addr: []string{cfg.Database.Address},
-		Auth: clickhouse.Auth{
-			Database: cfg.Database.Database,
-			Username: cfg.Database.Username,
-			Password: cfg.Database.Password,
-		},
-	})
+	var storage Storage
+	if opts.ReplayPath != "" {
+		storage, err = newJSONStorage(opts.OutputPath)
+	} else {
+		storage, err = newClickhouseStorage(cfg)
+	}
+	if err != nil {
+		log.Printf("can't open storage: %s", err)
+		return 1
+	}
+	defer storage.Close()
 
-	err = upMigrations(db)
+	err = collect(cfg, opts, storage)
 	if err != nil {
-		log.Printf("can't up migrations: %s", err)
+		log.Printf("can't collect statistic: %s", err)
 		return 1
 	}
 
-	httpClient := newHttpClient()
+	log.Println("statistic collected and saved")
+	return 0
+}
+
+// collect gets offers of area, builds statistics and writes them to storage
+func collect(cfg *Config, opts collectOptions, storage Storage) error {
+	if err := validateProximity(cfg); err != nil {
+		return fmt.Errorf("invalid proximity config: %w", err)
+	}
 
-	geojson, err := os.ReadFile(geojsonFilePath)
+	if cfg.Photos.Enabled && cfg.Photos.MaxWorkers < 1 {
+		return errors.New("photos max workers must be at least 1")
+	}
+
+	var transport http.RoundTripper = &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+	}
+
+	// replayed run is not the last one, so it doesn't write state of live runs
+	replay := opts.ReplayPath != ""
+	if replay && opts.Resume {
+		return errors.New("can't resume replayed run")
+	}
+
+	var replayer *archive.Replayer
+	if replay {
+		var err error
+		replayer, err = archive.NewReplayer(opts.ReplayPath)
+		if err != nil {
+			return fmt.Errorf("can't open replay archive: %w", err)
+		}
+
+		transport = replayer
+	}
+
+	if opts.ArchivePath != "" {
+		recorder, err := archive.NewRecorder(transport, opts.ArchivePath)
+		if err != nil {
+			return fmt.Errorf("can't create archive: %w", err)
+		}
+		defer func() {
+			if err := recorder.Close(); err != nil {
+				log.Printf("can't close archive: %s", err)
+			}
+		}()
+
+		transport = recorder
+	}
+
+	httpClient := newHttpClient(transport)
+
+	polygon, err := readPolygon(opts.Polygon, cfg)
 	if err != nil {
-		log.Printf("can't read polygon file: %s", err)
-		return 1
+		return fmt.Errorf("can't read polygon: %w", err)
+	}
+
+	parser := cian.NewParser(httpClient, cfg.Rucaptcha.APIKey, polygon, cfg.Cian.SearchType, cfg.Cian.SearchQuery, cfg.Cian.MaxCellSizeMeters, cfg.Cian.MaxWorkersCollectIds, cfg.Cian.MaxWorkersCollectOffers)
+
+	if cfg.Cian.BaseURL != "" {
+		parser.SetBaseURL(cfg.Cian.BaseURL)
+	}
+
+	parser.SetRateLimit(cfg.Cian.MaxRequestsPerSecond)
+
+	if cfg.Rucaptcha.BaseURL != "" {
+		err := parser.SetCaptchaBaseURL(cfg.Rucaptcha.BaseURL)
+		if err != nil {
+			return fmt.Errorf("can't set captcha base url: %w", err)
+		}
+	}
+
+	if cfg.Rucaptcha.PollingInterval > 0 {
+		parser.SetCaptchaPollingInterval(cfg.Rucaptcha.PollingInterval)
 	}
 
-	parser := cian.NewParser(httpClient, cfg.Rucaptcha.APIKey, string(geojson), cfg.Cian.SearchType, cfg.Cian.SearchQuery, cfg.Cian.MaxCellSizeMeters, cfg.Cian.MaxWorkersCollectIds, cfg.Cian.MaxWorkersCollectOffers)
+	var state *checkpoint.State
+	if cfg.Checkpoint.Directory != "" && !replay {
+		configHash, err := getConfigHash(opts.ConfigPath, polygon.ToWKB())
+		if err != nil {
+			return fmt.Errorf("can't get config hash: %w", err)
+		}
+
+		state, err = checkpoint.Open(cfg.Checkpoint.Directory, configHash, opts.Resume)
+		if err != nil {
+			return fmt.Errorf("can't open checkpoint: %w", err)
+		}
+		defer state.Close()
 
-	offerIDs, err := parser.GetOfferIDs()
+		parser.SetCheckpoint(state)
+	} else if opts.Resume {
+		return errors.New("can't resume: checkpoint directory is not configured")
+	}
+
+	grid, err := getSearchGrid(cfg, polygon, parser)
 	if err != nil {
-		log.Printf("can't get offer ids: %s", err)
-		return 1
+		return fmt.Errorf("can't get search grid: %w", err)
 	}
+	parser.SetGrid(grid)
 
-	offers, err := parser.GetOffers(offerIDs)
+	startTime := time.Now()
+
+	cells, err := parser.GetClusters()
 	if err != nil {
-		log.Printf("can't get offers: %s", err)
-		return 1
+		return fmt.Errorf("can't get clusters: %w", err)
+	}
+
+	gridCells, err := geo.GetGridCells(polygon, grid)
+	if err != nil {
+		return fmt.Errorf("can't get grid cells: %w", err)
+	}
+
+	if opts.GridPath != "" {
+		err = writeGrid(opts.GridPath, gridCells, cells)
+		if err != nil {
+			return fmt.Errorf("can't write grid: %w", err)
+		}
 	}
 
-	flatStat := getFlatStatistic(offers)
+	offerIDs := cian.GetOfferIDsFromCells(cells)
 
-	err = saveStatistic(db, time.Now(), flatStat)
+	offers, err := getOffers(parser, cfg, cells, offerIDs, cfg.Incremental.Enabled && !replay)
 	if err != nil {
-		log.Printf("can't save statistic: %s", err)
-		return 1
+		return fmt.Errorf("can't get offers: %w", err)
 	}
 
-	log.Println("statistic collected and saved")
-	return 0
+	cian.SetClusterFlags(offers, cian.GetClusterFlagsFromCells(cells))
+
+	// replayed rows get time of the archived run
+	now := time.Now()
+	if replayer != nil {
+		now = replayer.RecordedAt()
+	}
+
+	area := geo.NewArea(polygon)
+
+	if cfg.Statistic.OutsidePolygon != "" {
+		var outsideOffers []cian.Offer
+		offers, outsideOffers, err = filterOffersByArea(offers, area, cfg.Statistic.OutsidePolygon)
+		if err != nil {
+			return fmt.Errorf("can't filter offers by polygon: %w", err)
+		}
+		log.Printf("%d offers are outside of target polygon", len(outsideOffers))
+
+		err = saveOutsideOffers(storage, now, outsideOffers, cfg.Statistic.OutsidePolygon == outsidePolygonDrop)
+		if err != nil {
+			return fmt.Errorf("can't save outside offers: %w", err)
+		}
+	}
+
+	if len(cfg.Proximity.Layers) > 0 {
+		layers, err := loadProximityLayers(cfg)
+		if err != nil {
+			return fmt.Errorf("can't load proximity layers: %w", err)
+		}
+
+		setOfferProximity(offers, layers)
+
+		err = saveOfferProximity(storage, now, offers)
+		if err != nil {
+			return fmt.Errorf("can't save offer proximity: %w", err)
+		}
+	}
+
+	districts, err := getDistrictIndex(cfg, opts.Polygon)
+	if err != nil {
+		return fmt.Errorf("can't get districts: %w", err)
+	}
+
+	unmatchedOffers := setOfferLocations(offers, districts)
+	if districts != nil {
+		log.Printf("%d offers are outside of all districts", len(unmatchedOffers))
+
+		err = saveUnmatchedOffers(storage, now, unmatchedOffers)
+		if err != nil {
+			return fmt.Errorf("can't save unmatched offers: %w", err)
+		}
+	}
+
+	duplicateGroups := dedup.GetDuplicateGroups(offers, dedup.Params{
+		MaxDistanceMeters: cfg.Dedup.MaxDistanceMeters,
+		MaxAreaDiff:       cfg.Dedup.MaxAreaDiff,
+		MaxPriceDiff:      cfg.Dedup.MaxPriceDiff,
+	})
+
+	err = saveDuplicateGroups(storage, now, duplicateGroups)
+	if err != nil {
+		return fmt.Errorf("can't save duplicate groups: %w", err)
+	}
+
+	lifecycles, err := storage.LoadOfferLifecycles()
+	if err != nil {
+		return fmt.Errorf("can't load offer lifecycles: %w", err)
+	}
+
+	// partial runs don't see all offers of the area, so nothing is delisted after them
+	delist := !opts.Resume && !replay
+
+	err = saveOfferLifecycles(storage, now, updateOfferLifecycles(lifecycles, offers, area, delist, now))
+	if err != nil {
+		return fmt.Errorf("can't save offer lifecycles: %w", err)
+	}
+
+	statOffers := offers
+	if !cfg.Statistic.KeepDuplicates {
+		statOffers = dedup.Deduplicate(offers, duplicateGroups)
+		log.Printf("removed %d duplicate offers", len(offers)-len(statOffers))
+	}
+	statOffers = getInsideOffers(statOffers)
+
+	if cfg.Statistic.OffersPath != "" && !replay {
+		err = saveOffers(cfg.Statistic.OffersPath, statOffers)
+		if err != nil {
+			return fmt.Errorf("can't save offers: %w", err)
+		}
+	}
+
+	flatStat := getFlatStatistic(statOffers, getDaysOnMarket(lifecycles), cfg.Statistic.GroupBySellerType, cfg.Proximity.BucketLayer, cfg.Proximity.Buckets)
+
+	err = saveStatistic(storage, now, flatStat)
+	if err != nil {
+		return fmt.Errorf("can't save statistic: %w", err)
+	}
+
+	if grid.Type != geo.GridTypeGeohash && grid.Type != geo.GridTypeH3 {
+		log.Printf("%s grid cell ids are made of cell bounds, cell statistic is comparable only between runs with the same area and cell size", grid.Type)
+	}
+
+	cellStat := getCellStatistic(grid, gridCells, cells, statOffers)
+
+	err = saveCellStatistic(storage, now, grid.Type, cellStat)
+	if err != nil {
+		return fmt.Errorf("can't save cell statistic: %w", err)
+	}
+
+	newbuildingStat := getNewbuildingStatistic(statOffers)
+
+	err = saveNewbuildingStatistic(storage, now, newbuildingStat)
+	if err != nil {
+		return fmt.Errorf("can't save newbuilding statistic: %w", err)
+	}
+
+	err = saveNewbuildingPolygons(storage, now, cian.GetNewbuildingPolygonsFromCells(cells))
+	if err != nil {
+		return fmt.Errorf("can't save newbuilding polygons: %w", err)
+	}
+
+	agencyStat := getAgencyStatistic(statOffers, cfg.Statistic.TopAgenciesCount)
+
+	err = saveAgencyStatistic(storage, now, agencyStat)
+	if err != nil {
+		return fmt.Errorf("can't save agency statistic: %w", err)
+	}
+
+	if cfg.Photos.Enabled && !replay {
+		store, err := photos.NewStore(cfg.Photos.Directory)
+		if err != nil {
+			return fmt.Errorf("can't open photos store: %w", err)
+		}
+
+		err = collectPhotos(parser, store, offers, now, cfg.Photos.MaxWorkers)
+		if err != nil {
+			return fmt.Errorf("can't collect photos: %w", err)
+		}
+
+		offerIDs := make([]int64, len(offers))
+		for i, offer := range offers {
+			offerIDs[i] = offer.CianID
+		}
+
+		relistings := store.FindRelistings(offerIDs)
+		log.Printf("found %d probable relistings", len(relistings))
+
+		err = saveRelistings(storage, now, relistings)
+		if err != nil {
+			return fmt.Errorf("can't save relistings: %w", err)
+		}
+
+		// relistings are remembered in index only after they are saved
+		err = store.Save()
+		if err != nil {
+			return fmt.Errorf("can't save photos store: %w", err)
+		}
+	}
+
+	// density map is saved only after successful run, so resumed run splits cells the same way
+	if grid.Type == geo.GridTypeAdaptive && cfg.AdaptiveGrid.DensityPath != "" && !replay {
+		err = saveDensity(getDensityPath(cfg.AdaptiveGrid.DensityPath, polygon), getDensityFromCells(cells))
+		if err != nil {
+			return fmt.Errorf("can't save density map: %w", err)
+		}
+	}
+
+	if state != nil {
+		err = state.Remove()
+		if err != nil {
+			return fmt.Errorf("can't remove checkpoint: %w", err)
+		}
+	}
+
+	if cfg.Plan.StatsPath != "" && !replay {
+		err = saveRunStats(cfg.Plan.StatsPath, runStats{
+			CellSize: cfg.Cian.MaxCellSizeMeters,
+			Cells:    len(cells),
+			Offers:   len(offers),
+			Stats:    parser.Stats(),
+			Duration: time.Since(startTime),
+		})
+		if err != nil {
+			return fmt.Errorf("can't save run stats: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func runApplication() int {
+	command := "collect"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "collect":
+		return runCollect(args)
+	case "plan":
+		return runPlan(args)
+	case "grid":
+		return runGrid(args)
+	case "heatmap":
+		return runHeatmap(args)
+	case "interpolate":
+		return runInterpolate(args)
+	case "query":
+		return runQuery(args)
+	default:
+		log.Printf("unknown command: %s", command)
+		return 1
+	}
 }
 
 func main() {
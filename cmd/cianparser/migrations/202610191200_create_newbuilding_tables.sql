@@ -0,0 +1,44 @@
+-- +goose Up
+CREATE TABLE newbuilding_median_price
+(
+    date_time DateTime,
+    newbuilding_id UInt64,
+    name String,
+    rooms_count UInt8,
+    from_developer Bool,
+    cluster_from_developer Bool,
+    has_newobject Bool,
+    offers_count UInt32,
+    price_per_meter Float32
+) ENGINE = MergeTree()
+ORDER BY (date_time, newbuilding_id, rooms_count);
+
+CREATE TABLE newbuilding_polygon
+(
+    date_time DateTime,
+    newbuilding_id UInt64,
+    name String,
+    geometry String
+) ENGINE = ReplacingMergeTree(date_time)
+ORDER BY newbuilding_id;
+
+CREATE VIEW newbuilding_price_dynamics AS
+SELECT
+    date_time,
+    newbuilding_id,
+    name,
+    rooms_count,
+    from_developer,
+    cluster_from_developer,
+    has_newobject,
+    offers_count,
+    price_per_meter,
+    lagInFrame(price_per_meter) OVER w AS previous_price_per_meter,
+    lagInFrame(offers_count) OVER w AS previous_offers_count
+FROM newbuilding_median_price
+WINDOW w AS (PARTITION BY newbuilding_id, rooms_count, from_developer ORDER BY date_time ROWS BETWEEN 1 PRECEDING AND CURRENT ROW);
+
+-- +goose Down
+DROP VIEW newbuilding_price_dynamics;
+DROP TABLE newbuilding_polygon;
+DROP TABLE newbuilding_median_price;
@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE flat_median_price ADD COLUMN seller_type String DEFAULT '' AFTER rooms_count;
+
+CREATE TABLE agency_statistic
+(
+    date_time DateTime,
+    agency_name String,
+    agents_count UInt32,
+    offers_count UInt32,
+    compared_offers_count UInt32,
+    median_markup Float32
+) ENGINE = MergeTree()
+ORDER BY (date_time, agency_name);
+
+-- +goose Down
+DROP TABLE agency_statistic;
+ALTER TABLE flat_median_price DROP COLUMN seller_type;
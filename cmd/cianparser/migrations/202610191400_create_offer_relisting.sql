@@ -0,0 +1,12 @@
+-- +goose Up
+CREATE TABLE offer_relisting
+(
+    date_time DateTime,
+    offer_id UInt64,
+    previous_offer_id UInt64,
+    shared_photos UInt16
+) ENGINE = ReplacingMergeTree(date_time)
+ORDER BY (offer_id, previous_offer_id);
+
+-- +goose Down
+DROP TABLE offer_relisting;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE offer_duplicate_group
+(
+    date_time DateTime,
+    offer_id UInt64,
+    group_id UInt64
+) ENGINE = MergeTree()
+ORDER BY (date_time, group_id, offer_id);
+
+-- +goose Down
+DROP TABLE offer_duplicate_group;
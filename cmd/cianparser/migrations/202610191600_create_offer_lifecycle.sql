@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE offer_lifecycle
+(
+    updated_at DateTime,
+    offer_id UInt64,
+    location String,
+    category String,
+    rooms_count UInt8,
+    lat Float64,
+    lng Float64,
+    first_seen DateTime,
+    last_seen DateTime,
+    status LowCardinality(String),
+    first_price Float64,
+    last_price Float64
+) ENGINE = ReplacingMergeTree(updated_at)
+ORDER BY offer_id;
+
+ALTER TABLE flat_median_price ADD COLUMN days_on_market_p25 Float32 DEFAULT 0;
+ALTER TABLE flat_median_price ADD COLUMN days_on_market_p50 Float32 DEFAULT 0;
+ALTER TABLE flat_median_price ADD COLUMN days_on_market_p75 Float32 DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE flat_median_price DROP COLUMN days_on_market_p75;
+ALTER TABLE flat_median_price DROP COLUMN days_on_market_p50;
+ALTER TABLE flat_median_price DROP COLUMN days_on_market_p25;
+DROP TABLE offer_lifecycle;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE offer_outside_polygon
+(
+    date_time DateTime,
+    offer_id UInt64,
+    lat Float64,
+    lng Float64,
+    dropped Bool
+) ENGINE = MergeTree()
+ORDER BY (date_time, offer_id);
+
+-- +goose Down
+DROP TABLE offer_outside_polygon;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE cell_statistic
+(
+    date_time DateTime,
+    grid_type String,
+    cell_id String,
+    geometry String,
+    offers_count UInt32,
+    price_per_meter Float64,
+    min_price Float64,
+    max_price Float64
+) ENGINE = MergeTree()
+ORDER BY (grid_type, cell_id, date_time);
+
+-- +goose Down
+DROP TABLE cell_statistic;
@@ -0,0 +1,13 @@
+-- +goose Up
+CREATE TABLE offer_proximity
+(
+    date_time DateTime,
+    offer_id UInt64,
+    layer String,
+    name String,
+    distance_meters Float64
+) ENGINE = MergeTree()
+ORDER BY (date_time, layer, offer_id);
+
+-- +goose Down
+DROP TABLE offer_proximity;
@@ -0,0 +1,5 @@
+-- +goose Up
+ALTER TABLE flat_median_price ADD COLUMN distance_bucket String DEFAULT '' AFTER seller_type;
+
+-- +goose Down
+ALTER TABLE flat_median_price DROP COLUMN distance_bucket;
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"gonum.org/v1/gonum/stat"
+)
+
+type newbuildingKey struct {
+	ID            int64
+	RoomsCount    int
+	FromDeveloper bool
+}
+
+type newbuildingStatItem struct {
+	ID                   int64   `json:"id"`
+	Name                 string  `json:"name"`
+	RoomsCount           int     `json:"rooms_count"`
+	FromDeveloper        bool    `json:"from_developer"`
+	ClusterFromDeveloper bool    `json:"cluster_from_developer"`
+	HasNewobject         bool    `json:"has_newobject"`
+	OffersCount          int     `json:"offers_count"`
+	MedianPrice          float64 `json:"median_price"`
+}
+
+// getNewbuildingStatistic groups offers by complex, rooms and offer seller, cluster flags
+// are set if any offer of group was in cluster with them
+func getNewbuildingStatistic(offers []cian.Offer) []newbuildingStatItem {
+	names := make(map[int64]string)
+	groupedOffers := make(map[newbuildingKey][]float64)
+	groupedFlags := make(map[newbuildingKey]cian.ClusterFlags)
+	for _, offer := range offers {
+		if offer.Newbuilding == nil {
+			continue
+		}
+
+		pricePerMeter, err := offer.PricePerMeter()
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		key := newbuildingKey{
+			ID:            offer.Newbuilding.ID,
+			RoomsCount:    int(offer.RoomsCount),
+			FromDeveloper: offer.SellerType() == cian.SellerTypeDeveloper,
+		}
+
+		names[key.ID] = offer.Newbuilding.Name
+		groupedOffers[key] = append(groupedOffers[key], pricePerMeter)
+		groupedFlags[key] = cian.ClusterFlags{
+			IsAnyFromDeveloper: groupedFlags[key].IsAnyFromDeveloper || offer.ClusterFlags.IsAnyFromDeveloper,
+			HasNewobject:       groupedFlags[key].HasNewobject || offer.ClusterFlags.HasNewobject,
+		}
+	}
+
+	newbuildingStat := make([]newbuildingStatItem, 0, len(groupedOffers))
+	for key, value := range groupedOffers {
+		sort.Float64s(value)
+
+		newbuildingStat = append(newbuildingStat, newbuildingStatItem{
+			ID:                   key.ID,
+			Name:                 names[key.ID],
+			RoomsCount:           key.RoomsCount,
+			FromDeveloper:        key.FromDeveloper,
+			ClusterFromDeveloper: groupedFlags[key].IsAnyFromDeveloper,
+			HasNewobject:         groupedFlags[key].HasNewobject,
+			OffersCount:          len(value),
+			MedianPrice:          stat.Quantile(0.5, stat.Empirical, value, nil),
+		})
+	}
+
+	return newbuildingStat
+}
+
+func saveNewbuildingStatistic(storage Storage, timestamp time.Time, statistic []newbuildingStatItem) error {
+	rows := make([][]any, 0)
+	for _, row := range statistic {
+		rows = append(rows, []any{timestamp.UTC(), row.ID, row.Name, row.RoomsCount, row.FromDeveloper, row.ClusterFromDeveloper, row.HasNewobject, row.OffersCount, row.MedianPrice})
+	}
+
+	return storage.Insert("newbuilding_median_price", []string{"date_time", "newbuilding_id", "name", "rooms_count", "from_developer", "cluster_from_developer", "has_newobject", "offers_count", "price_per_meter"}, rows)
+}
+
+func saveNewbuildingPolygons(storage Storage, timestamp time.Time, polygons []cian.NewbuildingPolygon) error {
+	rows := make([][]any, 0)
+	for _, polygon := range polygons {
+		rows = append(rows, []any{timestamp.UTC(), polygon.ID, polygon.Name, string(polygon.Geometry)})
+	}
+
+	return storage.Insert("newbuilding_polygon", []string{"date_time", "newbuilding_id", "name", "geometry"}, rows)
+}
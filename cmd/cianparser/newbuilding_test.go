@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+)
+
+func TestNewbuildingStatisticSplitsMixedCluster(t *testing.T) {
+	newbuilding := &cian.Newbuilding{ID: 1, Name: "ЖК"}
+	flags := cian.ClusterFlags{IsAnyFromDeveloper: true, HasNewobject: true}
+
+	offers := []cian.Offer{
+		{CianID: 1, RoomsCount: 1, TotalArea: "40", BargainTerms: cian.BargainTerms{PriceRur: 4000000}, Newbuilding: newbuilding, IsFromBuilder: true, ClusterFlags: flags},
+		{CianID: 2, RoomsCount: 1, TotalArea: "40", BargainTerms: cian.BargainTerms{PriceRur: 4400000}, Newbuilding: newbuilding, IsByHomeowner: true, ClusterFlags: flags},
+	}
+
+	statistic := getNewbuildingStatistic(offers)
+	if len(statistic) != 2 {
+		t.Fatalf("got %d statistic rows, want 2", len(statistic))
+	}
+
+	for _, row := range statistic {
+		want := 100000.0
+		if !row.FromDeveloper {
+			want = 110000
+		}
+
+		if row.OffersCount != 1 || row.MedianPrice != want {
+			t.Errorf("from developer %v: got %d offers with median %f, want 1 offer with median %f", row.FromDeveloper, row.OffersCount, row.MedianPrice, want)
+		}
+		if !row.ClusterFromDeveloper || !row.HasNewobject {
+			t.Errorf("from developer %v: cluster flags are lost", row.FromDeveloper)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+)
+
+// saveOffers writes offers of the last run which statistic is made of,
+// they are read by heatmap, interpolate and query commands
+func saveOffers(path string, offers []cian.Offer) error {
+	data, err := json.Marshal(offers)
+	if err != nil {
+		return fmt.Errorf("can't marshal offers: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	err = os.WriteFile(tmpPath, data, 0o644)
+	if err != nil {
+		return fmt.Errorf("can't write offers file: %w", err)
+	}
+
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		return fmt.Errorf("can't replace offers file: %w", err)
+	}
+
+	return nil
+}
+
+// loadOffers reads offers of the last run, path is statistic.offers_path from config
+// if it is empty, missing file or file without offers is an error
+func loadOffers(cfg *Config, path string) ([]cian.Offer, error) {
+	if path == "" {
+		path = cfg.Statistic.OffersPath
+	}
+	if path == "" {
+		return nil, errors.New("offers file is not set and statistic.offers_path is not configured")
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("offers file %s doesn't exist, run collect first", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't read offers file: %w", err)
+	}
+
+	var offers []cian.Offer
+	err = json.Unmarshal(data, &offers)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse offers file: %w", err)
+	}
+
+	if len(offers) == 0 {
+		return nil, fmt.Errorf("no offers in %s", path)
+	}
+
+	return offers, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/photos"
+	"github.com/mishannn/cianparser-go/internal/utils"
+)
+
+// collectPhotos downloads photos of offers to store, store is not saved
+func collectPhotos(parser *cian.Parser, store *photos.Store, offers []cian.Offer, seenAt time.Time, maxWorkers int) error {
+	workerPool := utils.NewWorkerPool(func(offer cian.Offer) (struct{}, error) {
+		phashes := make([]uint64, 0, len(offer.Photos))
+		for _, photo := range offer.Photos {
+			storedPhoto, err := store.Fetch(photo.FullURL, parser.DownloadPhoto)
+			if err != nil {
+				log.Printf("can't fetch photo '%s' of offer %d: %s", photo.FullURL, offer.CianID, err)
+				continue
+			}
+
+			phashes = append(phashes, storedPhoto.PHash)
+		}
+
+		store.AddOffer(offer.CianID, seenAt, phashes)
+		return struct{}{}, nil
+	}, maxWorkers)
+	workerPool.OnProgress(func(current, total int) {
+		log.Printf("get photos progress: %d%%\n", (current * 100 / total))
+	})
+
+	_, err := workerPool.Map(context.Background(), offers)
+	if err != nil {
+		return fmt.Errorf("can't get photos: %w", err)
+	}
+
+	return nil
+}
+
+func saveRelistings(storage Storage, timestamp time.Time, relistings []photos.Relisting) error {
+	rows := make([][]any, 0)
+	for _, row := range relistings {
+		rows = append(rows, []any{timestamp.UTC(), row.OfferID, row.PreviousOfferID, row.SharedPhotos})
+	}
+
+	return storage.Insert("offer_relisting", []string{"date_time", "offer_id", "previous_offer_id", "shared_photos"}, rows)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"time"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/geo"
+)
+
+// runStats is saved after every run to estimate the next ones
+type runStats struct {
+	CellSize float64       `json:"cell_size"`
+	Cells    int           `json:"cells"`
+	Offers   int           `json:"offers"`
+	Stats    cian.Stats    `json:"stats"`
+	Duration time.Duration `json:"duration"`
+}
+
+func saveRunStats(path string, stats runStats) error {
+	statsJSON, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return fmt.Errorf("can't marshal run stats: %w", err)
+	}
+
+	err = os.WriteFile(path, statsJSON, 0o644)
+	if err != nil {
+		return fmt.Errorf("can't write run stats: %w", err)
+	}
+
+	return nil
+}
+
+func loadRunStats(path string) (*runStats, error) {
+	statsJSON, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't read run stats: %w", err)
+	}
+
+	var stats runStats
+	err = json.Unmarshal(statsJSON, &stats)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse run stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// estimatePhaseDuration returns duration of requests made by workers, limited by requests rate
+func estimatePhaseDuration(requests int, workers int, requestSeconds float64, maxRequestsPerSecond float64) time.Duration {
+	seconds := float64(requests) * requestSeconds / float64(max(workers, 1))
+	if maxRequestsPerSecond > 0 {
+		seconds = math.Max(seconds, float64(requests)/maxRequestsPerSecond)
+	}
+
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second)
+}
+
+func runPlan(args []string) int {
+	flags := flag.NewFlagSet("plan", flag.ExitOnError)
+
+	var configFilePath string
+	flags.StringVar(&configFilePath, "c", "config.yaml", "config file path")
+
+	var geojsonFilePath string
+	flags.StringVar(&geojsonFilePath, "f", "polygon.geojson", polygonFlagUsage)
+
+	flags.Parse(args)
+
+	cfg, err := newConfig(configFilePath)
+	if err != nil {
+		log.Printf("can't read config: %s", err)
+		return 1
+	}
+
+	polygon, err := readPolygon(geojsonFilePath, cfg)
+	if err != nil {
+		log.Printf("can't read polygon: %s", err)
+		return 1
+	}
+
+	grid, err := getSearchGrid(cfg, polygon, nil)
+	if err != nil {
+		log.Printf("can't get search grid: %s", err)
+		return 1
+	}
+
+	boundsList, err := geo.GetGridCellBounds(polygon, grid)
+	if err != nil {
+		log.Printf("can't get cell bounds list: %s", err)
+		return 1
+	}
+
+	var previous *runStats
+	if cfg.Plan.StatsPath != "" {
+		previous, err = loadRunStats(cfg.Plan.StatsPath)
+		if err != nil {
+			log.Printf("can't load previous run stats: %s", err)
+			return 1
+		}
+	}
+
+	cells := len(boundsList)
+	clustersDuration := estimatePhaseDuration(cells, cfg.Cian.MaxWorkersCollectIds, cfg.Plan.RequestSeconds, cfg.Cian.MaxRequestsPerSecond)
+
+	fmt.Printf("cells: %d\n", cells)
+	fmt.Printf("clusters phase: %d requests, ~%s\n", cells, clustersDuration)
+
+	if previous == nil || previous.Cells == 0 {
+		fmt.Println("offers phase: unknown, no previous run stats")
+		return 0
+	}
+
+	if previous.CellSize != cfg.Cian.MaxCellSizeMeters {
+		fmt.Printf("previous run used cell size %.0f, offers estimate may be inaccurate\n", previous.CellSize)
+	}
+
+	offers := int(math.Round(float64(cells) * float64(previous.Offers) / float64(previous.Cells)))
+	offerRequests := int(math.Ceil(float64(offers) / cian.OffersBatchSize))
+	offersDuration := estimatePhaseDuration(offerRequests, cfg.Cian.MaxWorkersCollectOffers, cfg.Plan.RequestSeconds, cfg.Cian.MaxRequestsPerSecond)
+
+	fmt.Printf("offers phase: ~%d offers, %d requests, ~%s\n", offers, offerRequests, offersDuration)
+	fmt.Printf("total: %d requests, ~%s\n", cells+offerRequests, clustersDuration+offersDuration)
+
+	previousRequests := previous.Stats.ClusterRequests + previous.Stats.OfferRequests
+	if previousRequests > 0 {
+		captchaRate := float64(previous.Stats.Captchas) / float64(previousRequests)
+		captchas := captchaRate * float64(cells+offerRequests)
+
+		fmt.Printf("captchas: ~%.0f (rate %.4f per request), ~%.2f spend\n", captchas, captchaRate, captchas*cfg.Plan.CaptchaPrice)
+	}
+
+	return 0
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	cianparser "github.com/mishannn/cianparser-go"
+	"github.com/mishannn/cianparser-go/internal/geo"
+	"github.com/twpayne/go-geos"
+)
+
+const polygonFlagUsage = "area: geojson, kml, wkt or wkb file path, minLon,minLat,maxLon,maxLat bbox or preset name (kazan, moscow)"
+
+// readPolygon reads area in any supported format, normalizes and preprocesses it before collecting,
+// skipped and repaired geometries are reported to log
+func readPolygon(input string, cfg *Config) (*geos.Geom, error) {
+	geojson, err := geo.ReadArea(input, cianparser.Presets)
+	if err != nil {
+		return nil, fmt.Errorf("can't read area: %w", err)
+	}
+
+	polygon, report, err := geo.NormalizeGeoJSON(geojson)
+	if err != nil {
+		return nil, fmt.Errorf("can't normalize polygon: %w", err)
+	}
+
+	if report.Changed() {
+		log.Printf("polygon was normalized:\n%s", report)
+	}
+
+	if cfg.Area.BufferMeters == 0 && cfg.Area.SimplifyMeters == 0 && len(cfg.Area.Exclusions) == 0 {
+		return polygon, nil
+	}
+
+	options := geo.PreprocessOptions{
+		BufferMeters:   cfg.Area.BufferMeters,
+		SimplifyMeters: cfg.Area.SimplifyMeters,
+	}
+
+	for _, exclusion := range cfg.Area.Exclusions {
+		exclusionGeoJSON, err := geo.ReadArea(exclusion, cianparser.Presets)
+		if err != nil {
+			return nil, fmt.Errorf("can't read exclusion area %s: %w", exclusion, err)
+		}
+		options.Exclusions = append(options.Exclusions, exclusionGeoJSON)
+	}
+
+	polygon, steps, err := geo.PreprocessGeom(polygon, options, getGrid(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("can't preprocess polygon: %w", err)
+	}
+
+	for _, step := range steps {
+		log.Printf("area %s: %d cells -> %d cells, %d removed", step.Name, step.CellsBefore, step.CellsAfter, step.CellsBefore-step.CellsAfter)
+	}
+
+	return polygon, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/geo"
+)
+
+const distanceBucketUnknown = "unknown"
+
+type proximityLayer struct {
+	name  string
+	layer *geo.POILayer
+}
+
+// validateProximity checks that bucket layer is one of layers and bucket edges are sorted
+func validateProximity(cfg *Config) error {
+	if cfg.Proximity.BucketLayer == "" {
+		return nil
+	}
+
+	if len(cfg.Proximity.Layers) == 0 {
+		return errors.New("bucket layer is set but there are no layers")
+	}
+
+	found := false
+	for _, layer := range cfg.Proximity.Layers {
+		if layer.Name == cfg.Proximity.BucketLayer {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("bucket layer %s is not in layers", cfg.Proximity.BucketLayer)
+	}
+
+	if !sort.Float64sAreSorted(cfg.Proximity.Buckets) {
+		return errors.New("buckets must be sorted")
+	}
+
+	return nil
+}
+
+func loadProximityLayers(cfg *Config) ([]proximityLayer, error) {
+	layers := make([]proximityLayer, 0, len(cfg.Proximity.Layers))
+	for _, layerCfg := range cfg.Proximity.Layers {
+		geojson, err := os.ReadFile(layerCfg.Path)
+		if err != nil {
+			return nil, fmt.Errorf("can't read %s layer file: %w", layerCfg.Name, err)
+		}
+
+		layer, err := geo.NewPOILayer(string(geojson), layerCfg.NameProperty)
+		if err != nil {
+			return nil, fmt.Errorf("can't load %s layer: %w", layerCfg.Name, err)
+		}
+
+		layers = append(layers, proximityLayer{name: layerCfg.Name, layer: layer})
+	}
+
+	return layers, nil
+}
+
+func setOfferProximity(offers []cian.Offer, layers []proximityLayer) {
+	for i := range offers {
+		offers[i].Nearest = make(map[string]cian.NearestFeature, len(layers))
+		for _, layer := range layers {
+			name, distance := layer.layer.Nearest(offers[i].Geo.Coordinates.Lng, offers[i].Geo.Coordinates.Lat)
+			offers[i].Nearest[layer.name] = cian.NearestFeature{Name: name, DistanceMeters: distance}
+		}
+	}
+}
+
+// getDistanceBucket returns bucket label like "0-500", "500-1500" or ">1500" for sorted bucket edges
+func getDistanceBucket(offer cian.Offer, layer string, edges []float64) string {
+	nearest, ok := offer.Nearest[layer]
+	if !ok {
+		return distanceBucketUnknown
+	}
+
+	from := 0.0
+	for _, edge := range edges {
+		if nearest.DistanceMeters < edge {
+			return formatMeters(from) + "-" + formatMeters(edge)
+		}
+		from = edge
+	}
+
+	return ">" + formatMeters(from)
+}
+
+func formatMeters(meters float64) string {
+	return strconv.FormatFloat(meters, 'f', -1, 64)
+}
+
+func saveOfferProximity(storage Storage, timestamp time.Time, offers []cian.Offer) error {
+	rows := make([][]any, 0)
+	for _, offer := range offers {
+		for layer, nearest := range offer.Nearest {
+			rows = append(rows, []any{timestamp.UTC(), offer.CianID, layer, nearest.Name, nearest.DistanceMeters})
+		}
+	}
+
+	return storage.Insert("offer_proximity", []string{"date_time", "offer_id", "layer", "name", "distance_meters"}, rows)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+)
+
+func TestGetDistanceBucket(t *testing.T) {
+	edges := []float64{500, 1000, 2000}
+
+	tests := []struct {
+		distance float64
+		want     string
+	}{
+		{0, "0-500"},
+		{499.9, "0-500"},
+		{500, "500-1000"},
+		{999.9, "500-1000"},
+		{1000, "1000-2000"},
+		{2000, ">2000"},
+		{15000, ">2000"},
+	}
+
+	for _, test := range tests {
+		offer := cian.Offer{Nearest: map[string]cian.NearestFeature{"metro": {Name: "Кремлёвская", DistanceMeters: test.distance}}}
+		if got := getDistanceBucket(offer, "metro", edges); got != test.want {
+			t.Errorf("%f m: got bucket %s, want %s", test.distance, got, test.want)
+		}
+	}
+
+	if got := getDistanceBucket(cian.Offer{}, "metro", edges); got != distanceBucketUnknown {
+		t.Errorf("got bucket %s for offer without nearest feature, want %s", got, distanceBucketUnknown)
+	}
+
+	if got := getDistanceBucket(cian.Offer{Nearest: map[string]cian.NearestFeature{"metro": {DistanceMeters: 10}}}, "metro", nil); got != ">0" {
+		t.Errorf("got bucket %s without edges, want >0", got)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	cianparser "github.com/mishannn/cianparser-go"
+	"github.com/mishannn/cianparser-go/internal/geo"
+	"github.com/mishannn/cianparser-go/internal/offerindex"
+)
+
+const (
+	queryFormatJSON    = "json"
+	queryFormatGeoJSON = "geojson"
+)
+
+func parseRoomsCounts(value string) ([]int, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	roomsCounts := make([]int, 0)
+	for _, part := range strings.Split(value, ",") {
+		roomsCount, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("can't parse rooms count %q: %w", part, err)
+		}
+		roomsCounts = append(roomsCounts, roomsCount)
+	}
+
+	return roomsCounts, nil
+}
+
+func writeQueryResults(w io.Writer, results []offerindex.Result, format string) error {
+	var data any
+	switch format {
+	case queryFormatJSON:
+		offers := make([]any, len(results))
+		for i, result := range results {
+			offers[i] = map[string]any{
+				"offer":           result.Offer,
+				"distance_meters": result.DistanceMeters,
+			}
+		}
+		data = offers
+	case queryFormatGeoJSON:
+		fc := geo.NewFeatureCollection()
+		for _, result := range results {
+			offer := result.Offer
+			properties := map[string]any{
+				"id":              offer.CianID,
+				"category":        offer.Category,
+				"rooms_count":     offer.RoomsCount,
+				"total_area":      offer.TotalArea,
+				"price":           offer.BargainTerms.PriceRur,
+				"address":         offer.Geo.Address,
+				"seller_type":     offer.SellerType(),
+				"distance_meters": result.DistanceMeters,
+			}
+
+			if pricePerMeter, err := offer.PricePerMeter(); err == nil {
+				properties["price_per_meter"] = pricePerMeter
+			}
+
+			fc.AddPoint(offer.Geo.Coordinates.Lng, offer.Geo.Coordinates.Lat, properties)
+		}
+		data = fc
+	default:
+		return fmt.Errorf("unknown output format: %s", format)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+func runQuery(args []string) int {
+	flags := flag.NewFlagSet("query", flag.ExitOnError)
+
+	var configFilePath string
+	flags.StringVar(&configFilePath, "c", "config.yaml", "config file path")
+
+	var offersFilePath string
+	flags.StringVar(&offersFilePath, "i", "", "offers file saved by collect, statistic.offers_path from config by default")
+
+	var point string
+	flags.StringVar(&point, "point", "", "radius query center as lng,lat")
+
+	var radius float64
+	flags.Float64Var(&radius, "radius", 1000, "radius query distance in meters")
+
+	var bbox string
+	flags.StringVar(&bbox, "bbox", "", "bbox query as minLon,minLat,maxLon,maxLat")
+
+	var polygon string
+	flags.StringVar(&polygon, "polygon", "", "polygon query area: file path or preset name")
+
+	var rooms string
+	flags.StringVar(&rooms, "rooms", "", "comma separated rooms counts")
+
+	var filter offerindex.Filter
+	flags.StringVar(&filter.Category, "category", "", "offer category")
+	flags.StringVar(&filter.SellerType, "seller", "", "seller type: owner, agent, developer or unknown")
+	flags.Float64Var(&filter.MinPrice, "min-price", 0, "min price")
+	flags.Float64Var(&filter.MaxPrice, "max-price", 0, "max price")
+	flags.Float64Var(&filter.MinPricePerMeter, "min-ppm", 0, "min price per meter")
+	flags.Float64Var(&filter.MaxPricePerMeter, "max-ppm", 0, "max price per meter")
+
+	var format string
+	flags.StringVar(&format, "format", queryFormatJSON, "output format: json or geojson")
+
+	var outputFilePath string
+	flags.StringVar(&outputFilePath, "o", "", "output file path, stdout by default")
+
+	flags.Parse(args)
+
+	cfg, err := newConfig(configFilePath)
+	if err != nil {
+		log.Printf("can't read config: %s", err)
+		return 1
+	}
+
+	if format != queryFormatJSON && format != queryFormatGeoJSON {
+		log.Printf("unknown output format: %s", format)
+		return 1
+	}
+
+	filter.RoomsCounts, err = parseRoomsCounts(rooms)
+	if err != nil {
+		log.Printf("can't parse rooms: %s", err)
+		return 1
+	}
+
+	offers, err := loadOffers(cfg, offersFilePath)
+	if err != nil {
+		log.Printf("can't load offers: %s", err)
+		return 1
+	}
+
+	index, err := offerindex.New(offers)
+	if err != nil {
+		log.Printf("can't build spatial index: %s", err)
+		return 1
+	}
+
+	var results []offerindex.Result
+	switch {
+	case point != "":
+		lng, lat, err := parsePoint(point)
+		if err != nil {
+			log.Printf("can't parse point: %s", err)
+			return 1
+		}
+
+		results = index.Radius(lng, lat, radius, filter)
+	case bbox != "":
+		bounds, err := geo.ParseBounds(bbox)
+		if err != nil {
+			log.Printf("can't parse bbox: %s", err)
+			return 1
+		}
+
+		results = index.Bbox(bounds, filter)
+	case polygon != "":
+		geojson, err := geo.ReadArea(polygon, cianparser.Presets)
+		if err != nil {
+			log.Printf("can't read polygon: %s", err)
+			return 1
+		}
+
+		results, err = index.Polygon(geojson, filter)
+		if err != nil {
+			log.Printf("can't query polygon: %s", err)
+			return 1
+		}
+	default:
+		log.Printf("one of -point, -bbox or -polygon is required")
+		return 1
+	}
+
+	output := os.Stdout
+	if outputFilePath != "" {
+		output, err = os.Create(outputFilePath)
+		if err != nil {
+			log.Printf("can't create output file: %s", err)
+			return 1
+		}
+		defer output.Close()
+	}
+
+	err = writeQueryResults(output, results, format)
+	if err != nil {
+		log.Printf("can't write results: %s", err)
+		return 1
+	}
+
+	log.Printf("%d offers found", len(results))
+	return 0
+}
@@ -1,12 +1,8 @@
 package main
 
 import (
-	"database/sql"
-	"fmt"
 	"log"
 	"sort"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/mishannn/cianparser-go/internal/cian"
@@ -17,83 +13,90 @@ type flatKey struct {
 	Category   string
 	Location   string
 	RoomsCount int
+	SellerType string
+
+	DistanceBucket string
 }
 
 type flatStatItem struct {
 	Location    string  `json:"location"`
 	Category    string  `json:"category"`
 	RoomsCount  int     `json:"rooms_count"`
+	SellerType  string  `json:"seller_type"`
 	MedianPrice float64 `json:"median_price"`
-}
-
-func getDistrictString(addresses []cian.Address) string {
-	parts := make([]string, 0)
 
-	for _, address := range addresses {
-		if address.GeoType == "location" || address.GeoType == "district" {
-			parts = append(parts, address.FullName)
-		}
-	}
+	DistanceBucket string `json:"distance_bucket"`
 
-	return strings.Join(parts, ", ")
+	DaysOnMarketP25 float64 `json:"days_on_market_p25"`
+	DaysOnMarketP50 float64 `json:"days_on_market_p50"`
+	DaysOnMarketP75 float64 `json:"days_on_market_p75"`
 }
 
-func getFlatStatistic(offers []cian.Offer) []flatStatItem {
+// getFlatStatistic groups offers by location, category and rooms, distance buckets are used
+// if distanceLayer is not empty
+func getFlatStatistic(offers []cian.Offer, daysOnMarket map[int64]float64, groupBySellerType bool, distanceLayer string, distanceBuckets []float64) []flatStatItem {
 	groupedOffers := make(map[flatKey][]float64)
+	groupedDays := make(map[flatKey][]float64)
 	for _, offer := range offers {
-		totalArea, err := strconv.ParseFloat(offer.TotalArea, 64)
+		pricePerMeter, err := offer.PricePerMeter()
 		if err != nil {
-			log.Printf("can't parse flat area '%s': %s", offer.TotalArea, err)
+			log.Print(err)
 			continue
 		}
 
 		key := flatKey{
-			Location:   getDistrictString(offer.Geo.Address),
+			Location:   offer.Location,
 			RoomsCount: int(offer.RoomsCount),
 			Category:   offer.Category,
 		}
 
-		groupedOffers[key] = append(groupedOffers[key], offer.BargainTerms.PriceRur/totalArea)
+		if groupBySellerType {
+			key.SellerType = offer.SellerType()
+		}
+
+		if distanceLayer != "" {
+			key.DistanceBucket = getDistanceBucket(offer, distanceLayer, distanceBuckets)
+		}
+
+		groupedOffers[key] = append(groupedOffers[key], pricePerMeter)
+
+		if days, ok := daysOnMarket[offer.CianID]; ok {
+			groupedDays[key] = append(groupedDays[key], days)
+		}
 	}
 
 	offersWithMedianPricePerMeter := make([]flatStatItem, 0, len(groupedOffers))
 	for key, value := range groupedOffers {
 		sort.Float64s(value)
 
-		offersWithMedianPricePerMeter = append(offersWithMedianPricePerMeter, flatStatItem{
+		item := flatStatItem{
 			Location:    key.Location,
 			Category:    key.Category,
 			RoomsCount:  key.RoomsCount,
+			SellerType:  key.SellerType,
 			MedianPrice: stat.Quantile(0.5, stat.Empirical, value, nil),
-		})
-	}
 
-	return offersWithMedianPricePerMeter
-}
+			DistanceBucket: key.DistanceBucket,
+		}
 
-func saveStatistic(db *sql.DB, timestamp time.Time, statistic []flatStatItem) error {
-	tx, err := db.Begin()
-	if err != nil {
-		return fmt.Errorf("can't begin statistic tx: %w", err)
-	}
-	defer tx.Rollback()
+		if days := groupedDays[key]; len(days) > 0 {
+			sort.Float64s(days)
+			item.DaysOnMarketP25 = stat.Quantile(0.25, stat.Empirical, days, nil)
+			item.DaysOnMarketP50 = stat.Quantile(0.5, stat.Empirical, days, nil)
+			item.DaysOnMarketP75 = stat.Quantile(0.75, stat.Empirical, days, nil)
+		}
 
-	batch, err := tx.Prepare("INSERT INTO flat_median_price (date_time, location, category, rooms_count, price_per_meter) VALUES (?, ?, ?, ?, ?)")
-	if err != nil {
-		return fmt.Errorf("can't prepare statistic SQL: %w", err)
+		offersWithMedianPricePerMeter = append(offersWithMedianPricePerMeter, item)
 	}
 
-	for _, row := range statistic {
-		_, err := batch.Exec(timestamp.UTC(), row.Location, row.Category, row.RoomsCount, row.MedianPrice)
-		if err != nil {
-			return fmt.Errorf("can't write statistic row: %w", err)
-		}
-	}
+	return offersWithMedianPricePerMeter
+}
 
-	err = tx.Commit()
-	if err != nil {
-		return fmt.Errorf("can't write statistic data: %w", err)
+func saveStatistic(storage Storage, timestamp time.Time, statistic []flatStatItem) error {
+	rows := make([][]any, 0)
+	for _, row := range statistic {
+		rows = append(rows, []any{timestamp.UTC(), row.Location, row.Category, row.RoomsCount, row.SellerType, row.DistanceBucket, row.MedianPrice, row.DaysOnMarketP25, row.DaysOnMarketP50, row.DaysOnMarketP75})
 	}
 
-	return nil
+	return storage.Insert("flat_median_price", []string{"date_time", "location", "category", "rooms_count", "seller_type", "distance_bucket", "price_per_meter", "days_on_market_p25", "days_on_market_p50", "days_on_market_p75"}, rows)
 }
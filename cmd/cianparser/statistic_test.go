@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/cian/ciantest"
+	"github.com/mishannn/cianparser-go/internal/geo"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// syntheticFixturePath is shared with parser tests, offers in it are generated by ciantest
+const syntheticFixturePath = "../../internal/cian/testdata/synthetic_kazan.json"
+
+const fixtureArea = "49.100,55.780,49.115,55.790"
+
+const flatStatisticGoldenPath = "testdata/synthetic_flat_statistic.json"
+
+func getFixtureOffers(t *testing.T) []cian.Offer {
+	t.Helper()
+
+	fixture, err := ciantest.NewCassette(syntheticFixturePath, ciantest.CassetteReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	geojson, err := geo.ReadArea(fixtureArea, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	polygon, _, err := geo.NormalizeGeoJSON(geojson)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parser := cian.NewParser(newHttpClient(fixture), "", polygon, "flatsale", nil, 1000, 1, 1)
+
+	cells, err := parser.GetClusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offers, err := parser.GetOffers(cian.GetOfferIDsFromCells(cells))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return offers
+}
+
+func TestFlatStatisticSyntheticFixture(t *testing.T) {
+	offers := getFixtureOffers(t)
+	setOfferLocations(offers, nil)
+
+	statistic := getFlatStatistic(offers, nil, true, "", nil)
+	sort.Slice(statistic, func(i, j int) bool {
+		a, b := statistic[i], statistic[j]
+		if a.Location != b.Location {
+			return a.Location < b.Location
+		}
+		if a.RoomsCount != b.RoomsCount {
+			return a.RoomsCount < b.RoomsCount
+		}
+		return a.SellerType < b.SellerType
+	})
+
+	got, err := json.MarshalIndent(statistic, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *update {
+		err := os.WriteFile(flatStatisticGoldenPath, got, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(flatStatisticGoldenPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != string(want) {
+		t.Errorf("flat statistic differs from %s, run with -update to accept:\n%s", flatStatisticGoldenPath, got)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+)
+
+// Storage keeps collected rows and offer lifecycles between runs
+type Storage interface {
+	Insert(table string, columns []string, rows [][]any) error
+	LoadOfferLifecycles() (map[int64]offerLifecycle, error)
+	Close() error
+}
+
+type clickhouseStorage struct {
+	db *sql.DB
+}
+
+// newClickhouseStorage connects to ClickHouse and applies migrations
+func newClickhouseStorage(cfg *Config) (*clickhouseStorage, error) {
+	db := clickhouse.OpenDB(&clickhouse.Options{
+		Addr: []string{cfg.Database.Address},
+		Auth: clickhouse.Auth{
+			Database: cfg.Database.Database,
+			Username: cfg.Database.Username,
+			Password: cfg.Database.Password,
+		},
+	})
+
+	err := upMigrations(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &clickhouseStorage{db: db}, nil
+}
+
+// Insert writes rows in one transaction, values of every row follow columns order
+func (s *clickhouseStorage) Insert(table string, columns []string, rows [][]any) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("can't begin %s tx: %w", table, err)
+	}
+	defer tx.Rollback()
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	batch, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), placeholders))
+	if err != nil {
+		return fmt.Errorf("can't prepare %s SQL: %w", table, err)
+	}
+
+	for _, row := range rows {
+		_, err := batch.Exec(row...)
+		if err != nil {
+			return fmt.Errorf("can't write %s row: %w", table, err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("can't write %s data: %w", table, err)
+	}
+
+	return nil
+}
+
+func (s *clickhouseStorage) LoadOfferLifecycles() (map[int64]offerLifecycle, error) {
+	return loadOfferLifecycles(s.db)
+}
+
+func (s *clickhouseStorage) Close() error {
+	return s.db.Close()
+}
+
+// jsonStorage writes rows as JSON lines instead of database, it is used on replay
+// to check statistics without touching production tables
+type jsonStorage struct {
+	writer  io.WriteCloser
+	encoder *json.Encoder
+}
+
+// newJSONStorage writes rows to file at path or to stdout when path is empty
+func newJSONStorage(path string) (*jsonStorage, error) {
+	var writer io.WriteCloser = os.Stdout
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("can't create output file: %w", err)
+		}
+		writer = file
+	}
+
+	return &jsonStorage{
+		writer:  writer,
+		encoder: json.NewEncoder(writer),
+	}, nil
+}
+
+// Insert writes every row as object with table name and values by column
+func (s *jsonStorage) Insert(table string, columns []string, rows [][]any) error {
+	for _, row := range rows {
+		values := make(map[string]any, len(columns)+1)
+		for i, column := range columns {
+			values[column] = row[i]
+		}
+		values["table"] = table
+
+		err := s.encoder.Encode(values)
+		if err != nil {
+			return fmt.Errorf("can't write %s row: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+// LoadOfferLifecycles returns no lifecycles, so replay doesn't depend on database state
+func (s *jsonStorage) LoadOfferLifecycles() (map[int64]offerLifecycle, error) {
+	return make(map[int64]offerLifecycle), nil
+}
+
+func (s *jsonStorage) Close() error {
+	if s.writer == os.Stdout {
+		return nil
+	}
+
+	return s.writer.Close()
+}
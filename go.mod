@@ -5,6 +5,7 @@ go 1.21.5
 require (
 	github.com/2captcha/2captcha-go v1.1.4
 	github.com/ClickHouse/clickhouse-go/v2 v2.16.0
+	github.com/mmcloughlin/geohash v0.10.0
 	github.com/paulmach/orb v0.10.0
 	github.com/pressly/goose/v3 v3.16.0
 	github.com/twpayne/go-geos v0.14.0
@@ -0,0 +1,43 @@
+package archive
+
+import (
+	"encoding/base64"
+	"net/http"
+	"time"
+	"unicode/utf8"
+)
+
+// Record is one archived request with its response, bodies are stored as text,
+// binary response bodies like photos are base64 encoded
+type Record struct {
+	Timestamp          time.Time   `json:"timestamp"`
+	Method             string      `json:"method"`
+	URL                string      `json:"url"`
+	RequestBody        string      `json:"request_body"`
+	Status             int         `json:"status"`
+	ResponseHeader     http.Header `json:"response_header"`
+	ResponseBody       string      `json:"response_body"`
+	ResponseBodyBase64 bool        `json:"response_body_base64,omitempty"`
+}
+
+func (r *Record) setResponseBody(body []byte) {
+	if utf8.Valid(body) {
+		r.ResponseBody = string(body)
+		return
+	}
+
+	r.ResponseBody = base64.StdEncoding.EncodeToString(body)
+	r.ResponseBodyBase64 = true
+}
+
+func (r *Record) getResponseBody() ([]byte, error) {
+	if r.ResponseBodyBase64 {
+		return base64.StdEncoding.DecodeString(r.ResponseBody)
+	}
+
+	return []byte(r.ResponseBody), nil
+}
+
+func recordKey(method string, url string, body string) string {
+	return method + " " + url + " " + body
+}
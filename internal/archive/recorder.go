@@ -0,0 +1,95 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Recorder is a transport which writes every request and response to gzipped JSONL file
+type Recorder struct {
+	next http.RoundTripper
+
+	mu      sync.Mutex
+	file    *os.File
+	gzip    *gzip.Writer
+	encoder *json.Encoder
+}
+
+func NewRecorder(next http.RoundTripper, path string) (*Recorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't create archive file: %w", err)
+	}
+
+	gzipWriter := gzip.NewWriter(file)
+
+	return &Recorder{
+		next:    next,
+		file:    file,
+		gzip:    gzipWriter,
+		encoder: json.NewEncoder(gzipWriter),
+	}, nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("can't read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	timestamp := time.Now()
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("can't read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record := Record{
+		Timestamp:      timestamp.UTC(),
+		Method:         req.Method,
+		URL:            req.URL.String(),
+		RequestBody:    string(reqBody),
+		Status:         resp.StatusCode,
+		ResponseHeader: resp.Header,
+	}
+	record.setResponseBody(respBody)
+
+	err = r.encoder.Encode(record)
+	if err != nil {
+		return nil, fmt.Errorf("can't write archive record: %w", err)
+	}
+
+	return resp, nil
+}
+
+// Close flushes archive, it must be called after the last request
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return errors.Join(r.gzip.Close(), r.file.Close())
+}
@@ -0,0 +1,116 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Replayer is a transport which serves responses from archive written by Recorder.
+// Captcha redirects are not replayed, so replay never needs captcha solving.
+type Replayer struct {
+	mu         sync.Mutex
+	records    map[string][]Record
+	recordedAt time.Time
+}
+
+func NewReplayer(path string) (*Replayer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't open archive file: %w", err)
+	}
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("can't read archive file: %w", err)
+	}
+	defer gzipReader.Close()
+
+	replayer := &Replayer{
+		records: make(map[string][]Record),
+	}
+
+	decoder := json.NewDecoder(bufio.NewReader(gzipReader))
+	for {
+		var record Record
+		err := decoder.Decode(&record)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("can't parse archive record: %w", err)
+		}
+
+		if replayer.recordedAt.IsZero() {
+			replayer.recordedAt = record.Timestamp
+		}
+
+		if record.Status == http.StatusFound {
+			continue
+		}
+
+		key := recordKey(record.Method, record.URL, record.RequestBody)
+		replayer.records[key] = append(replayer.records[key], record)
+	}
+
+	return replayer, nil
+}
+
+// RecordedAt returns time of the first archived request
+func (r *Replayer) RecordedAt() time.Time {
+	return r.recordedAt
+}
+
+// RoundTrip returns archived responses of the same request in recorded order,
+// the last one is repeated when request was made more times than recorded
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("can't read request body: %w", err)
+		}
+	}
+
+	key := recordKey(req.Method, req.URL.String(), string(reqBody))
+
+	r.mu.Lock()
+	records := r.records[key]
+	if len(records) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("response not found in archive: %s %s", req.Method, req.URL)
+	}
+	record := records[0]
+	if len(records) > 1 {
+		r.records[key] = records[1:]
+	}
+	r.mu.Unlock()
+
+	respBody, err := record.getResponseBody()
+	if err != nil {
+		return nil, fmt.Errorf("can't decode archived response body: %w", err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", record.Status, http.StatusText(record.Status)),
+		StatusCode:    record.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        record.ResponseHeader,
+		Body:          io.NopCloser(bytes.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+		Request:       req,
+	}, nil
+}
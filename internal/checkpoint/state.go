@@ -0,0 +1,262 @@
+package checkpoint
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+)
+
+const metaFileName = "meta.json"
+const cellsFileName = "cells.jsonl"
+const offersFileName = "offers.jsonl"
+
+type meta struct {
+	ConfigHash string `json:"config_hash"`
+}
+
+type offersBatch struct {
+	IDs    []int64      `json:"ids"`
+	Offers []cian.Offer `json:"offers"`
+}
+
+// State keeps finished cells and offer batches in state directory, it implements cian.Checkpoint
+type State struct {
+	dir string
+	mu  sync.Mutex
+
+	cells      map[cian.Bounds]cian.CellClusters
+	fetchedIDs map[int64]struct{}
+	offers     []cian.Offer
+
+	cellsFile  *os.File
+	offersFile *os.File
+}
+
+// Open opens state directory, previous progress is loaded only when resume is true
+// and config hash matches hash of checkpoint
+func Open(dir string, configHash string, resume bool) (*State, error) {
+	state := &State{
+		dir:        dir,
+		cells:      make(map[cian.Bounds]cian.CellClusters),
+		fetchedIDs: make(map[int64]struct{}),
+		offers:     make([]cian.Offer, 0),
+	}
+
+	if resume {
+		err := state.load(configHash)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		err := state.reset(configHash)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+
+	state.cellsFile, err = os.OpenFile(filepath.Join(dir, cellsFileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("can't open cells checkpoint: %w", err)
+	}
+
+	state.offersFile, err = os.OpenFile(filepath.Join(dir, offersFileName), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		state.cellsFile.Close()
+		return nil, fmt.Errorf("can't open offers checkpoint: %w", err)
+	}
+
+	return state, nil
+}
+
+// removeFiles removes only checkpoint files, other files in state directory are kept
+func (s *State) removeFiles() error {
+	for _, name := range []string{metaFileName, cellsFileName, offersFileName} {
+		err := os.Remove(filepath.Join(s.dir, name))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *State) reset(configHash string) error {
+	err := s.removeFiles()
+	if err != nil {
+		return fmt.Errorf("can't remove checkpoint files: %w", err)
+	}
+
+	err = os.MkdirAll(s.dir, 0o755)
+	if err != nil {
+		return fmt.Errorf("can't create state directory: %w", err)
+	}
+
+	metaJSON, err := json.Marshal(meta{ConfigHash: configHash})
+	if err != nil {
+		return fmt.Errorf("can't marshal checkpoint meta: %w", err)
+	}
+
+	err = os.WriteFile(filepath.Join(s.dir, metaFileName), metaJSON, 0o644)
+	if err != nil {
+		return fmt.Errorf("can't write checkpoint meta: %w", err)
+	}
+
+	return nil
+}
+
+func (s *State) load(configHash string) error {
+	metaJSON, err := os.ReadFile(filepath.Join(s.dir, metaFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return errors.New("checkpoint not found")
+	}
+	if err != nil {
+		return fmt.Errorf("can't read checkpoint meta: %w", err)
+	}
+
+	var checkpointMeta meta
+	err = json.Unmarshal(metaJSON, &checkpointMeta)
+	if err != nil {
+		return fmt.Errorf("can't parse checkpoint meta: %w", err)
+	}
+
+	if checkpointMeta.ConfigHash != configHash {
+		return errors.New("config has changed since checkpoint")
+	}
+
+	err = readJSONLines(filepath.Join(s.dir, cellsFileName), func(cell cian.CellClusters) {
+		s.cells[cell.Bounds] = cell
+	})
+	if err != nil {
+		return fmt.Errorf("can't read cells checkpoint: %w", err)
+	}
+
+	err = readJSONLines(filepath.Join(s.dir, offersFileName), func(batch offersBatch) {
+		for _, id := range batch.IDs {
+			s.fetchedIDs[id] = struct{}{}
+		}
+		s.offers = append(s.offers, batch.Offers...)
+	})
+	if err != nil {
+		return fmt.Errorf("can't read offers checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+// readJSONLines calls f for every line of file, truncated last line of interrupted write
+// is skipped and cut off the file, so next lines are appended after the last complete one
+func readJSONLines[T any](path string, f func(value T)) error {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var offset int64
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) == 0 {
+				return nil
+			}
+			return file.Truncate(offset)
+		}
+		if err != nil {
+			return err
+		}
+
+		var value T
+		err = json.Unmarshal(line, &value)
+		if err != nil {
+			return err
+		}
+
+		f(value)
+		offset += int64(len(line))
+	}
+}
+
+func writeJSONLine(file *os.File, value any) error {
+	line, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
+
+func (s *State) GetCell(bounds cian.Bounds) (cian.CellClusters, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cell, ok := s.cells[bounds]
+	return cell, ok
+}
+
+func (s *State) SaveCell(cell cian.CellClusters) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cells[cell.Bounds] = cell
+	return writeJSONLine(s.cellsFile, cell)
+}
+
+func (s *State) IsOfferFetched(id int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.fetchedIDs[id]
+	return ok
+}
+
+func (s *State) GetFetchedOffers() []cian.Offer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.offers
+}
+
+func (s *State) SaveOffers(ids []int64, offers []cian.Offer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		s.fetchedIDs[id] = struct{}{}
+	}
+	s.offers = append(s.offers, offers...)
+
+	return writeJSONLine(s.offersFile, offersBatch{IDs: ids, Offers: offers})
+}
+
+func (s *State) Close() error {
+	return errors.Join(s.cellsFile.Close(), s.offersFile.Close())
+}
+
+// Remove removes checkpoint files after successful run
+func (s *State) Remove() error {
+	err := s.Close()
+	if err != nil {
+		return fmt.Errorf("can't close checkpoint files: %w", err)
+	}
+
+	err = s.removeFiles()
+	if err != nil {
+		return fmt.Errorf("can't remove checkpoint files: %w", err)
+	}
+
+	return nil
+}
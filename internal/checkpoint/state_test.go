@@ -0,0 +1,83 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+)
+
+func TestResumeAfterTruncatedLine(t *testing.T) {
+	dir := t.TempDir()
+
+	state, err := Open(dir, "hash", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = state.SaveOffers([]int64{1}, []cian.Offer{{CianID: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state.Close()
+
+	// interrupted write of the second batch
+	offersFile, err := os.OpenFile(filepath.Join(dir, offersFileName), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offersFile.WriteString(`{"ids":[2],"offe`)
+	offersFile.Close()
+
+	state, err = Open(dir, "hash", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = state.SaveOffers([]int64{3}, []cian.Offer{{CianID: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state.Close()
+
+	state, err = Open(dir, "hash", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer state.Close()
+
+	for id, fetched := range map[int64]bool{1: true, 2: false, 3: true} {
+		if state.IsOfferFetched(id) != fetched {
+			t.Errorf("offer %d fetched = %t, want %t", id, !fetched, fetched)
+		}
+	}
+}
+
+func TestResetKeepsForeignFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	foreignPath := filepath.Join(dir, "notes.txt")
+	err := os.WriteFile(foreignPath, []byte("keep"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := Open(dir, "hash", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = state.Remove()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(foreignPath); err != nil {
+		t.Errorf("foreign file is removed: %s", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, metaFileName)); !os.IsNotExist(err) {
+		t.Errorf("checkpoint meta is not removed")
+	}
+}
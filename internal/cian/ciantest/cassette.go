@@ -0,0 +1,223 @@
+package ciantest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// CassetteRecordEnv enables cassettes record mode when set to 1
+const CassetteRecordEnv = "CIANTEST_RECORD"
+
+const scrubbedValue = "scrubbed"
+
+// secret query and form parameters, captcha solution is sent to Cian captcha form
+var scrubbedParams = []string{"g-recaptcha-response"}
+
+type CassetteMode int
+
+const (
+	CassetteReplay CassetteMode = iota
+	CassetteRecord
+)
+
+func CassetteModeFromEnv() CassetteMode {
+	if os.Getenv(CassetteRecordEnv) == "1" {
+		return CassetteRecord
+	}
+	return CassetteReplay
+}
+
+// Interaction is one recorded request with response, bodies are expected to be text
+type Interaction struct {
+	Method         string      `json:"method"`
+	URL            string      `json:"url"`
+	RequestBody    string      `json:"request_body"`
+	Status         int         `json:"status"`
+	ResponseHeader http.Header `json:"response_header"`
+	ResponseBody   string      `json:"response_body"`
+}
+
+// Cassette is a transport which records real interactions to fixture file
+// in record mode and serves them in replay mode, secrets are scrubbed before saving
+type Cassette struct {
+	path string
+	mode CassetteMode
+	next http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	replayed     map[string]int
+}
+
+func NewCassette(path string, mode CassetteMode, next http.RoundTripper) (*Cassette, error) {
+	cassette := &Cassette{
+		path:         path,
+		mode:         mode,
+		next:         next,
+		interactions: make([]Interaction, 0),
+		replayed:     make(map[string]int),
+	}
+
+	if mode == CassetteRecord {
+		return cassette, nil
+	}
+
+	cassetteJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't read cassette (set %s=1 to record it): %w", CassetteRecordEnv, err)
+	}
+
+	err = json.Unmarshal(cassetteJSON, &cassette.interactions)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse cassette: %w", err)
+	}
+
+	return cassette, nil
+}
+
+func scrubValues(values url.Values) {
+	for _, param := range scrubbedParams {
+		if values.Has(param) {
+			values.Set(param, scrubbedValue)
+		}
+	}
+}
+
+func scrubURL(u *url.URL) string {
+	scrubbed := *u
+	query := scrubbed.Query()
+	scrubValues(query)
+	scrubbed.RawQuery = query.Encode()
+	return scrubbed.String()
+}
+
+func scrubRequestBody(header http.Header, body []byte) string {
+	if !strings.HasPrefix(header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		return string(body)
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return string(body)
+	}
+
+	scrubValues(form)
+	return form.Encode()
+}
+
+func interactionKey(method, url, body string) string {
+	return method + " " + url + " " + body
+}
+
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("can't read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	interaction := Interaction{
+		Method:      req.Method,
+		URL:         scrubURL(req.URL),
+		RequestBody: scrubRequestBody(req.Header, reqBody),
+	}
+
+	if c.mode == CassetteReplay {
+		return c.replay(req, interaction)
+	}
+
+	resp, err := c.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("can't read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction.Status = resp.StatusCode
+	interaction.ResponseHeader = resp.Header.Clone()
+	interaction.ResponseHeader.Del("Set-Cookie")
+	interaction.ResponseBody = string(respBody)
+
+	c.mu.Lock()
+	c.interactions = append(c.interactions, interaction)
+	c.mu.Unlock()
+
+	return resp, nil
+}
+
+// replay returns the n-th recorded interaction for the n-th equal request,
+// captcha redirects are skipped, so replay never needs captcha solving
+func (c *Cassette) replay(req *http.Request, interaction Interaction) (*http.Response, error) {
+	key := interactionKey(interaction.Method, interaction.URL, interaction.RequestBody)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	skip := c.replayed[key]
+	for _, recorded := range c.interactions {
+		if recorded.Status == http.StatusFound || interactionKey(recorded.Method, recorded.URL, recorded.RequestBody) != key {
+			continue
+		}
+
+		if skip > 0 {
+			skip--
+			continue
+		}
+
+		c.replayed[key]++
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+			StatusCode:    recorded.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        recorded.ResponseHeader,
+			Body:          io.NopCloser(strings.NewReader(recorded.ResponseBody)),
+			ContentLength: int64(len(recorded.ResponseBody)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, errors.New("interaction not found in cassette: " + key)
+}
+
+// Save writes recorded interactions to cassette file, it does nothing in replay mode
+func (c *Cassette) Save() error {
+	if c.mode != CassetteRecord {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cassetteJSON, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("can't marshal cassette: %w", err)
+	}
+
+	err = os.WriteFile(c.path, cassetteJSON, 0o644)
+	if err != nil {
+		return fmt.Errorf("can't write cassette: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package ciantest
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+
+	"github.com/twpayne/go-geos"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+)
+
+var districts = []string{"Центральный", "Северный", "Южный", "Западный", "Восточный"}
+
+var agencies = []string{"Этажи", "Инком", "Миэль", "Самолет Плюс"}
+
+// maxPointAttempts limits random points search for polygons which cover small part of their bounds
+const maxPointAttempts = 1000
+
+// GenerateOffers returns count synthetic offers placed inside geojson polygon, the same seed gives the same offers
+func GenerateOffers(geojson string, count int, seed int64) ([]cian.Offer, error) {
+	geom, err := geos.NewGeomFromGeoJSON(geojson)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse geojson: %w", err)
+	}
+
+	polygon := geom.Prepare()
+	bounds := geom.Bounds()
+	random := rand.New(rand.NewSource(seed))
+
+	offers := make([]cian.Offer, 0, count)
+	for i := 0; i < count; i++ {
+		var lat, lng float64
+
+		found := false
+		for attempt := 0; attempt < maxPointAttempts; attempt++ {
+			lng = bounds.MinX + random.Float64()*bounds.Width()
+			lat = bounds.MinY + random.Float64()*bounds.Height()
+
+			if polygon.Contains(geos.NewPointFromXY(lng, lat)) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return nil, errors.New("can't place offer inside polygon")
+		}
+
+		roomsCount := 1 + random.Intn(4)
+		totalArea := float64(20+roomsCount*18) + random.Float64()*20
+
+		offer := cian.Offer{
+			CianID: int64(100000 + i),
+			Geo: cian.Geo{
+				Coordinates: cian.Coordinates{Lat: lat, Lng: lng},
+				Address: []cian.Address{
+					{FullName: "Город", GeoType: "location"},
+					{FullName: "р-н " + districts[random.Intn(len(districts))], GeoType: "district"},
+				},
+			},
+			Category:    "flatSale",
+			RoomsCount:  roomsCount,
+			FloorNumber: 1 + random.Intn(25),
+			TotalArea:   strconv.FormatFloat(totalArea, 'f', 1, 64),
+			BargainTerms: cian.BargainTerms{
+				PriceRur: float64(int(totalArea*(200000+random.Float64()*200000)/1000) * 1000),
+			},
+		}
+
+		switch random.Intn(4) {
+		case 0:
+			offer.IsByHomeowner = true
+			offer.UserID = int64(200000 + i)
+		case 1:
+			offer.IsFromBuilder = true
+			offer.UserID = 300000
+			offer.Newbuilding = &cian.Newbuilding{ID: int64(400000 + random.Intn(5)), Name: "ЖК Тестовый"}
+		default:
+			agency := random.Intn(len(agencies))
+			offer.UserID = int64(500000 + agency)
+			offer.User = &cian.User{IsAgent: true, AgencyName: agencies[agency]}
+		}
+
+		offers = append(offers, offer)
+	}
+
+	return offers, nil
+}
@@ -0,0 +1,267 @@
+// Package ciantest provides fake Cian API server for end-to-end tests
+package ciantest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+)
+
+const captchaPath = "/captcha/"
+const getClustersForMapPath = "/search-offers-index-map/v1/get-clusters-for-map/"
+const getOffersByIDsPath = "/search-offers/v1/get-offers-by-ids-desktop/"
+
+// 2captcha API paths, fake server solves captcha by itself
+const captchaInPath = "/in.php"
+const captchaResPath = "/res.php"
+
+const CaptchaSiteKey = "ciantest-site-key"
+const CaptchaToken = "ciantest-captcha-token"
+
+// clusterCellDegrees is a size of square which offers are grouped to one cluster in
+const clusterCellDegrees = 0.002
+
+type Options struct {
+	// Offers are served by server, see GenerateOffers
+	Offers []cian.Offer
+
+	// CaptchaEvery puts captcha wall on every n-th API request until captcha is solved
+	CaptchaEvery int
+	// ErrorEvery answers every n-th API request with 500
+	ErrorEvery int
+	// Delay is added to every API response
+	Delay time.Duration
+	// MaxClusterOfferIDs truncates offer ids of every cluster like Cian does for dense areas
+	MaxClusterOfferIDs int
+}
+
+type Stats struct {
+	Requests        int
+	ClusterRequests int
+	OfferRequests   int
+	Captchas        int
+	Errors          int
+}
+
+// Server is a fake Cian API, it also implements 2captcha API, so its URL can be used for both
+type Server struct {
+	*httptest.Server
+
+	options Options
+	offers  map[int64]cian.Offer
+
+	mu             sync.Mutex
+	stats          Stats
+	captchaWallSet bool
+}
+
+func NewServer(options Options) *Server {
+	s := &Server{
+		options: options,
+		offers:  make(map[int64]cian.Offer, len(options.Offers)),
+	}
+
+	for _, offer := range options.Offers {
+		s.offers[offer.CianID] = offer
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(captchaPath, s.handleCaptcha)
+	mux.HandleFunc(getClustersForMapPath, s.apiHandler(s.handleGetClusters))
+	mux.HandleFunc(getOffersByIDsPath, s.apiHandler(s.handleGetOffers))
+	mux.HandleFunc(captchaInPath, s.handleCaptchaIn)
+	mux.HandleFunc(captchaResPath, s.handleCaptchaRes)
+
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+func (s *Server) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stats
+}
+
+// apiHandler applies failure injection before handler
+func (s *Server) apiHandler(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.options.Delay > 0 {
+			time.Sleep(s.options.Delay)
+		}
+
+		s.mu.Lock()
+		s.stats.Requests++
+		requests := s.stats.Requests
+
+		if s.options.CaptchaEvery > 0 && requests%s.options.CaptchaEvery == 0 {
+			s.captchaWallSet = true
+		}
+
+		if s.captchaWallSet {
+			s.stats.Captchas++
+			s.mu.Unlock()
+
+			http.Redirect(w, r, captchaPath, http.StatusFound)
+			return
+		}
+
+		if s.options.ErrorEvery > 0 && requests%s.options.ErrorEvery == 0 {
+			s.stats.Errors++
+			s.mu.Unlock()
+
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		s.mu.Unlock()
+
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+func insideBounds(coordinates cian.Coordinates, bounds cian.Bounds) bool {
+	return coordinates.Lat <= bounds.TopLeft.Lat && coordinates.Lat >= bounds.BottomRight.Lat &&
+		coordinates.Lng >= bounds.TopLeft.Lng && coordinates.Lng <= bounds.BottomRight.Lng
+}
+
+func (s *Server) handleGetClusters(w http.ResponseWriter, r *http.Request) {
+	var reqBody cian.GetClustersRequestBody
+	err := json.NewDecoder(r.Body).Decode(&reqBody)
+	if err != nil || len(reqBody.Bbox) == 0 {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.stats.ClusterRequests++
+	s.mu.Unlock()
+
+	type clusterKey struct {
+		X int
+		Y int
+	}
+
+	bounds := reqBody.Bbox[0]
+	clusters := make(map[clusterKey]*cian.Cluster)
+	keys := make([]clusterKey, 0)
+	offersCount := 0
+	for _, offer := range s.options.Offers {
+		coordinates := offer.Geo.Coordinates
+		if !insideBounds(coordinates, bounds) {
+			continue
+		}
+		offersCount++
+
+		key := clusterKey{
+			X: int(math.Floor(coordinates.Lng / clusterCellDegrees)),
+			Y: int(math.Floor(coordinates.Lat / clusterCellDegrees)),
+		}
+
+		cluster, ok := clusters[key]
+		if !ok {
+			cluster = &cian.Cluster{
+				Coordinates: coordinates,
+				Geohash:     fmt.Sprintf("%d:%d", key.X, key.Y),
+				MinPrice:    offer.BargainTerms.PriceRur,
+				MaxPrice:    offer.BargainTerms.PriceRur,
+			}
+			clusters[key] = cluster
+			keys = append(keys, key)
+		}
+
+		cluster.Count++
+		cluster.MinPrice = math.Min(cluster.MinPrice, offer.BargainTerms.PriceRur)
+		cluster.MaxPrice = math.Max(cluster.MaxPrice, offer.BargainTerms.PriceRur)
+		cluster.IsAnyFromDeveloper = cluster.IsAnyFromDeveloper || offer.IsFromBuilder
+		cluster.HasNewobject = cluster.HasNewobject || offer.Newbuilding != nil
+
+		if s.options.MaxClusterOfferIDs <= 0 || len(cluster.ClusterOfferIds) < s.options.MaxClusterOfferIDs {
+			cluster.ClusterOfferIds = append(cluster.ClusterOfferIds, offer.CianID)
+		}
+	}
+
+	filtered := make([]cian.Cluster, 0, len(keys))
+	for _, key := range keys {
+		filtered = append(filtered, *clusters[key])
+	}
+
+	writeJSON(w, cian.GetClustersResponseBody{
+		JSONQuery:   reqBody.JSONQuery,
+		Bbox:        bounds,
+		Filtered:    filtered,
+		OffersCount: offersCount,
+	})
+}
+
+func (s *Server) handleGetOffers(w http.ResponseWriter, r *http.Request) {
+	var reqBody cian.GetOffersByIDsRequestBody
+	err := json.NewDecoder(r.Body).Decode(&reqBody)
+	if err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.stats.OfferRequests++
+	s.mu.Unlock()
+
+	offers := make([]cian.Offer, 0, len(reqBody.CianOfferIDS))
+	for _, id := range reqBody.CianOfferIDS {
+		if offer, ok := s.offers[id]; ok {
+			offers = append(offers, offer)
+		}
+	}
+
+	writeJSON(w, cian.GetOffersByIDsResponseBody{OffersSerialized: offers})
+}
+
+func (s *Server) handleCaptcha(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, "<html><script>grecaptcha.render('captcha', {'sitekey': '%s'});</script></html>", CaptchaSiteKey)
+	case http.MethodPost:
+		if r.FormValue("g-recaptcha-response") != CaptchaToken {
+			http.Error(w, "wrong captcha", http.StatusForbidden)
+			return
+		}
+
+		s.mu.Lock()
+		s.captchaWallSet = false
+		s.mu.Unlock()
+
+		http.Redirect(w, r, "/", http.StatusFound)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleCaptchaIn(w http.ResponseWriter, r *http.Request) {
+	if r.FormValue("googlekey") != CaptchaSiteKey {
+		fmt.Fprint(w, "ERROR_WRONG_GOOGLEKEY")
+		return
+	}
+
+	fmt.Fprint(w, "OK|1")
+}
+
+func (s *Server) handleCaptchaRes(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, "OK|%s", CaptchaToken)
+}
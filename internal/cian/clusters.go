@@ -1,6 +1,12 @@
 package cian
 
-import "github.com/twpayne/go-geos"
+import (
+	"encoding/json"
+
+	"github.com/twpayne/go-geos"
+
+	"github.com/mishannn/cianparser-go/internal/utils"
+)
 
 type Coordinates struct {
 	Lat float64 `json:"lat"`
@@ -34,18 +40,103 @@ type GetClustersRequestBody struct {
 }
 
 type GetClustersResponseBody struct {
-	JSONQuery            map[string]any `json:"jsonQuery"`
-	QueryString          string         `json:"queryString"`
-	NonGeoQueryString    string         `json:"nonGeoQueryString"`
-	ExtendedJSONQuery    map[string]any `json:"extendedJsonQuery"`
-	ExtendedQueryString  string         `json:"extendedQueryString"`
-	IsNewobject          bool           `json:"isNewobject"`
-	NewbuildingsPolygons []any          `json:"newbuildingsPolygons"`
-	Bbox                 Bounds         `json:"bbox"`
-	Precision            int            `json:"precision"`
-	Extended             []any          `json:"extended"`
-	Filtered             []Cluster      `json:"filtered"`
-	OffersCount          int            `json:"offersCount"`
+	JSONQuery            map[string]any       `json:"jsonQuery"`
+	QueryString          string               `json:"queryString"`
+	NonGeoQueryString    string               `json:"nonGeoQueryString"`
+	ExtendedJSONQuery    map[string]any       `json:"extendedJsonQuery"`
+	ExtendedQueryString  string               `json:"extendedQueryString"`
+	IsNewobject          bool                 `json:"isNewobject"`
+	NewbuildingsPolygons []NewbuildingPolygon `json:"newbuildingsPolygons"`
+	Bbox                 Bounds               `json:"bbox"`
+	Precision            int                  `json:"precision"`
+	Extended             []any                `json:"extended"`
+	Filtered             []Cluster            `json:"filtered"`
+	OffersCount          int                  `json:"offersCount"`
+}
+
+// NewbuildingPolygon is a new-building complex outline shown on the map
+type NewbuildingPolygon struct {
+	ID       int64           `json:"id"`
+	Name     string          `json:"name"`
+	Geometry json.RawMessage `json:"geometry"`
+}
+
+// CellClusters is a result of clusters request for one grid cell
+type CellClusters struct {
+	Bounds               Bounds
+	Clusters             []Cluster
+	NewbuildingsPolygons []NewbuildingPolygon
+}
+
+// ClusterFlags has cluster values which are not present in offer
+type ClusterFlags struct {
+	IsAnyFromDeveloper bool
+	HasNewobject       bool
+}
+
+// ClusterPrices is a price range of cluster which contains offer
+type ClusterPrices struct {
+	MinPrice float64
+	MaxPrice float64
+}
+
+func GetOfferIDsFromCells(cells []CellClusters) []int64 {
+	offerIDs := make([]int64, 0)
+	for _, cell := range cells {
+		for _, cluster := range cell.Clusters {
+			offerIDs = append(offerIDs, cluster.ClusterOfferIds...)
+		}
+	}
+
+	return utils.RemoveDuplicateInt64(offerIDs)
+}
+
+func GetNewbuildingPolygonsFromCells(cells []CellClusters) []NewbuildingPolygon {
+	seen := make(map[int64]struct{})
+	polygons := make([]NewbuildingPolygon, 0)
+	for _, cell := range cells {
+		for _, polygon := range cell.NewbuildingsPolygons {
+			if _, ok := seen[polygon.ID]; ok {
+				continue
+			}
+			seen[polygon.ID] = struct{}{}
+			polygons = append(polygons, polygon)
+		}
+	}
+
+	return polygons
+}
+
+func GetClusterFlagsFromCells(cells []CellClusters) map[int64]ClusterFlags {
+	flags := make(map[int64]ClusterFlags)
+	for _, cell := range cells {
+		for _, cluster := range cell.Clusters {
+			for _, id := range cluster.ClusterOfferIds {
+				flags[id] = ClusterFlags{
+					IsAnyFromDeveloper: flags[id].IsAnyFromDeveloper || cluster.IsAnyFromDeveloper,
+					HasNewobject:       flags[id].HasNewobject || cluster.HasNewobject,
+				}
+			}
+		}
+	}
+
+	return flags
+}
+
+func GetClusterPricesFromCells(cells []CellClusters) map[int64]ClusterPrices {
+	prices := make(map[int64]ClusterPrices)
+	for _, cell := range cells {
+		for _, cluster := range cell.Clusters {
+			for _, id := range cluster.ClusterOfferIds {
+				prices[id] = ClusterPrices{
+					MinPrice: cluster.MinPrice,
+					MaxPrice: cluster.MaxPrice,
+				}
+			}
+		}
+	}
+
+	return prices
 }
 
 func GeosBoundsToCianBounds(bounds *geos.Bounds) Bounds {
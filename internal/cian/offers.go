@@ -1,5 +1,10 @@
 package cian
 
+import (
+	"fmt"
+	"strconv"
+)
+
 type GetOffersByIDsRequestBody struct {
 	CianOfferIDS []int64        `json:"cianOfferIds"`
 	JSONQuery    map[string]any `json:"jsonQuery"`
@@ -11,11 +16,101 @@ type GetOffersByIDsResponseBody struct {
 
 // Offer has only important values
 type Offer struct {
+	CianID       int64        `json:"cianId"`       // need
 	Geo          Geo          `json:"geo"`          // need
 	Category     string       `json:"category"`     // need
 	RoomsCount   int          `json:"roomsCount"`   // need
+	FloorNumber  int          `json:"floorNumber"`  // need
 	TotalArea    string       `json:"totalArea"`    // need
 	BargainTerms BargainTerms `json:"bargainTerms"` // need
+	Newbuilding  *Newbuilding `json:"newbuilding"`  // need
+
+	UserID        int64 `json:"userId"`        // need
+	User          *User `json:"user"`          // need
+	IsByHomeowner bool  `json:"isByHomeowner"` // need
+	IsFromBuilder bool  `json:"isFromBuilder"` // need
+
+	Photos []Photo `json:"photos"` // need
+
+	// ClusterFlags are filled from clusters by SetClusterFlags
+	ClusterFlags ClusterFlags `json:"-"`
+	// Location is filled by caller from address or district polygons
+	Location string `json:"-"`
+	// OutsidePolygon is set by caller for offers outside of target polygon
+	OutsidePolygon bool `json:"-"`
+	// Nearest has nearest feature of every poi layer by layer name, it is filled by caller
+	Nearest map[string]NearestFeature `json:"-"`
+}
+
+// NearestFeature is a poi closest to offer
+type NearestFeature struct {
+	Name           string
+	DistanceMeters float64
+}
+
+// Newbuilding has only important values
+type Newbuilding struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// Photo has only important values
+type Photo struct {
+	FullURL string `json:"fullUrl"`
+}
+
+// User has only important values
+type User struct {
+	IsAgent    bool   `json:"isAgent"`
+	AgencyName string `json:"agencyName"`
+}
+
+const (
+	SellerTypeOwner     = "owner"
+	SellerTypeAgent     = "agent"
+	SellerTypeDeveloper = "developer"
+	SellerTypeUnknown   = "unknown"
+)
+
+func (o *Offer) SellerType() string {
+	switch {
+	case o.IsFromBuilder:
+		return SellerTypeDeveloper
+	case o.IsByHomeowner:
+		return SellerTypeOwner
+	case o.User != nil && o.User.IsAgent:
+		return SellerTypeAgent
+	default:
+		return SellerTypeUnknown
+	}
+}
+
+func (o *Offer) GetTotalArea() (float64, error) {
+	totalArea, err := strconv.ParseFloat(o.TotalArea, 64)
+	if err != nil {
+		return 0, fmt.Errorf("can't parse flat area '%s': %w", o.TotalArea, err)
+	}
+
+	return totalArea, nil
+}
+
+func (o *Offer) PricePerMeter() (float64, error) {
+	totalArea, err := o.GetTotalArea()
+	if err != nil {
+		return 0, err
+	}
+
+	if totalArea == 0 {
+		return 0, fmt.Errorf("flat area of offer %d is zero", o.CianID)
+	}
+
+	return o.BargainTerms.PriceRur / totalArea, nil
+}
+
+func SetClusterFlags(offers []Offer, flags map[int64]ClusterFlags) {
+	for i := range offers {
+		offers[i].ClusterFlags = flags[offers[i].CianID]
+	}
 }
 
 // BargainTerms has only important values
@@ -25,8 +120,8 @@ type BargainTerms struct {
 
 // Geo has only important values
 type Geo struct {
-	// Coordinates     Coordinates   `json:"coordinates"`
-	Address []Address `json:"address"`
+	Coordinates Coordinates `json:"coordinates"`
+	Address     []Address   `json:"address"`
 }
 
 type Address struct {
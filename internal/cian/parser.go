@@ -12,8 +12,10 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 
 	api2captcha "github.com/2captcha/2captcha-go"
+	"github.com/twpayne/go-geos"
 	"golang.org/x/sync/singleflight"
 
 	"github.com/mishannn/cianparser-go/internal/geo"
@@ -21,34 +23,68 @@ import (
 )
 
 const cianBaseURL = "https://api.cian.ru"
-const cianCaptchaURL = cianBaseURL + "/captcha/"
-const cianGetClustersForMapURL = cianBaseURL + "/search-offers-index-map/v1/get-clusters-for-map/"
-const cianGetOffersByIDsURL = cianBaseURL + "/search-offers/v1/get-offers-by-ids-desktop/"
+const cianCaptchaPath = "/captcha/"
+const cianGetClustersForMapPath = "/search-offers-index-map/v1/get-clusters-for-map/"
+const cianGetOffersByIDsPath = "/search-offers/v1/get-offers-by-ids-desktop/"
+
+// OffersBatchSize is a number of offers requested at once
+const OffersBatchSize = 28
+
+// serverErrorRetries is a number of times request is repeated after 5xx response
+const serverErrorRetries = 3
 
 const userAgent = "Mozilla/5.0 (Windows NT 10.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/118.0.0.0 Safari/537.36"
 
 var captchaKeyRegex = regexp.MustCompile(`'sitekey': '(.*?)'`)
 
+var errServerUnavailable = errors.New("server is unavailable")
+
 type Parser struct {
 	httpClient *http.Client
+	baseURL    string
 
-	geojson                 string
+	polygon                 *geos.Geom
 	searchType              string
 	searchFilters           map[string]JSONQueryItem
-	searchCellSize          float64
+	grid                    geo.Grid
 	maxWorkersCollectIDs    int
 	maxWorkersCollectOffers int
 	captchaGroup            singleflight.Group
 	captchaClient           *api2captcha.Client
+	checkpoint              Checkpoint
+	rateLimiter             *utils.RateLimiter
+
+	statsMu sync.Mutex
+	stats   Stats
+}
+
+// Stats has counters of requests made by parser
+type Stats struct {
+	ClusterRequests int `json:"cluster_requests"`
+	OfferRequests   int `json:"offer_requests"`
+	Captchas        int `json:"captchas"`
+	Retries         int `json:"retries"`
+	// TruncatedClusters is a number of clusters which have less offer ids than offers
+	TruncatedClusters int `json:"truncated_clusters"`
+}
+
+// Checkpoint keeps finished cells and offer batches to continue interrupted run
+type Checkpoint interface {
+	GetCell(bounds Bounds) (CellClusters, bool)
+	SaveCell(cell CellClusters) error
+	IsOfferFetched(id int64) bool
+	GetFetchedOffers() []Offer
+	SaveOffers(ids []int64, offers []Offer) error
 }
 
-func NewParser(httpClient *http.Client, captchaApiKey string, geojson string, searchType string, searchFilters map[string]JSONQueryItem, searchCellSize float64, maxWorkersCollectIDs int, maxWorkersCollectOffers int) *Parser {
+func NewParser(httpClient *http.Client, captchaApiKey string, polygon *geos.Geom, searchType string, searchFilters map[string]JSONQueryItem, searchCellSize float64, maxWorkersCollectIDs int, maxWorkersCollectOffers int) *Parser {
 	return &Parser{
 		httpClient:              httpClient,
-		geojson:                 geojson,
+		baseURL:                 cianBaseURL,
+		polygon:                 polygon,
 		searchType:              searchType,
 		searchFilters:           searchFilters,
-		searchCellSize:          searchCellSize,
+		grid:                    geo.Grid{Type: geo.GridTypeRect, CellSize: searchCellSize},
 		maxWorkersCollectIDs:    maxWorkersCollectIDs,
 		maxWorkersCollectOffers: maxWorkersCollectOffers,
 		captchaGroup:            singleflight.Group{},
@@ -56,8 +92,57 @@ func NewParser(httpClient *http.Client, captchaApiKey string, geojson string, se
 	}
 }
 
+// SetBaseURL changes Cian API address, it is used to run parser against fake server
+func (p *Parser) SetBaseURL(baseURL string) {
+	p.baseURL = strings.TrimSuffix(baseURL, "/")
+}
+
+// SetCaptchaBaseURL changes captcha solving service address
+func (p *Parser) SetCaptchaBaseURL(baseURL string) error {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return fmt.Errorf("can't parse captcha base url: %w", err)
+	}
+
+	p.captchaClient.BaseURL = u
+	return nil
+}
+
+// SetCaptchaPollingInterval changes interval in seconds between captcha result checks
+func (p *Parser) SetCaptchaPollingInterval(seconds int) {
+	p.captchaClient.PollingInterval = seconds
+}
+
+// SetGrid changes how search polygon is split to cells
+func (p *Parser) SetGrid(grid geo.Grid) {
+	p.grid = grid
+}
+
+// SetRateLimit limits requests to Cian API, zero means no limit
+func (p *Parser) SetRateLimit(requestsPerSecond float64) {
+	p.rateLimiter = utils.NewRateLimiter(requestsPerSecond)
+}
+
+func (p *Parser) Stats() Stats {
+	p.statsMu.Lock()
+	defer p.statsMu.Unlock()
+
+	return p.stats
+}
+
+func (p *Parser) countStats(f func(stats *Stats)) {
+	p.statsMu.Lock()
+	defer p.statsMu.Unlock()
+
+	f(&p.stats)
+}
+
+func (p *Parser) SetCheckpoint(checkpoint Checkpoint) {
+	p.checkpoint = checkpoint
+}
+
 func (p *Parser) getCaptchaSiteKey() (string, error) {
-	req, err := http.NewRequest(http.MethodGet, cianCaptchaURL, nil)
+	req, err := http.NewRequest(http.MethodGet, p.baseURL+cianCaptchaPath, nil)
 	if err != nil {
 		return "", fmt.Errorf("can't create request: %w", err)
 	}
@@ -91,7 +176,7 @@ func (p *Parser) sendCaptchaCode(code string) error {
 	form.Add("g-recaptcha-response", code)
 	form.Add("redirect_url", "")
 
-	req, err := http.NewRequest(http.MethodPost, cianCaptchaURL, strings.NewReader(form.Encode()))
+	req, err := http.NewRequest(http.MethodPost, p.baseURL+cianCaptchaPath, strings.NewReader(form.Encode()))
 	if err != nil {
 		return fmt.Errorf("can't create request: %w", err)
 	}
@@ -119,6 +204,7 @@ func (p *Parser) sendCaptchaCode(code string) error {
 func (p *Parser) solveCaptcha() error {
 	_, err, _ := p.captchaGroup.Do("captcha", func() (any, error) {
 		log.Println("solving captcha...")
+		p.countStats(func(stats *Stats) { stats.Captchas++ })
 
 		siteKey, err := p.getCaptchaSiteKey()
 		if err != nil {
@@ -127,7 +213,7 @@ func (p *Parser) solveCaptcha() error {
 
 		cap := api2captcha.ReCaptcha{
 			SiteKey: siteKey,
-			Url:     cianCaptchaURL,
+			Url:     p.baseURL + cianCaptchaPath,
 		}
 
 		code, err := p.captchaClient.Solve(cap.ToRequest())
@@ -163,7 +249,7 @@ func (p *Parser) getJSONQuery() map[string]any {
 	return jsonQuery
 }
 
-func (p *Parser) getClustersByBounds(bounds Bounds) ([]Cluster, error) {
+func (p *Parser) getClustersByBounds(bounds Bounds) (*GetClustersResponseBody, error) {
 	reqBody := GetClustersRequestBody{
 		Zoom:      15,
 		Bbox:      []Bounds{bounds},
@@ -175,12 +261,15 @@ func (p *Parser) getClustersByBounds(bounds Bounds) ([]Cluster, error) {
 		return nil, fmt.Errorf("can't marshal request body: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, cianGetClustersForMapURL, bytes.NewReader(reqBodyJSON))
+	req, err := http.NewRequest(http.MethodPost, p.baseURL+cianGetClustersForMapPath, bytes.NewReader(reqBodyJSON))
 	if err != nil {
 		return nil, fmt.Errorf("can't create request: %w", err)
 	}
 	req.Header.Set("User-Agent", userAgent)
 
+	p.rateLimiter.Wait()
+	p.countStats(func(stats *Stats) { stats.ClusterRequests++ })
+
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("can't do request: %w", err)
@@ -201,6 +290,10 @@ func (p *Parser) getClustersByBounds(bounds Bounds) ([]Cluster, error) {
 		return p.getClustersByBounds(bounds)
 	}
 
+	if resp.StatusCode >= 500 {
+		return nil, fmt.Errorf("%w: %d, %s", errServerUnavailable, resp.StatusCode, respBody)
+	}
+
 	if resp.StatusCode != 200 {
 		return nil, fmt.Errorf("server sent http error: %d, %s", resp.StatusCode, respBody)
 	}
@@ -211,7 +304,7 @@ func (p *Parser) getClustersByBounds(bounds Bounds) ([]Cluster, error) {
 		return nil, fmt.Errorf("can't parse response body: %w, %s", err, respBody)
 	}
 
-	return clustersResponseBody.Filtered, nil
+	return &clustersResponseBody, nil
 }
 
 func (p *Parser) getOffers(ids []int64) ([]Offer, error) {
@@ -225,12 +318,15 @@ func (p *Parser) getOffers(ids []int64) ([]Offer, error) {
 		return nil, fmt.Errorf("can't marshal request body: %w", err)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, cianGetOffersByIDsURL, bytes.NewReader(reqBodyJSON))
+	req, err := http.NewRequest(http.MethodPost, p.baseURL+cianGetOffersByIDsPath, bytes.NewReader(reqBodyJSON))
 	if err != nil {
 		return nil, fmt.Errorf("can't create request: %w", err)
 	}
 	req.Header.Set("User-Agent", userAgent)
 
+	p.rateLimiter.Wait()
+	p.countStats(func(stats *Stats) { stats.OfferRequests++ })
+
 	resp, err := p.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("can't do request: %w", err)
@@ -251,6 +347,10 @@ func (p *Parser) getOffers(ids []int64) ([]Offer, error) {
 		return p.getOffers(ids)
 	}
 
+	if resp.StatusCode >= 500 {
+		return nil, fmt.Errorf("%w: %d, %s", errServerUnavailable, resp.StatusCode, respBody)
+	}
+
 	if resp.StatusCode != 200 {
 		return nil, fmt.Errorf("server sent http error: %d, %s", resp.StatusCode, respBody)
 	}
@@ -264,41 +364,179 @@ func (p *Parser) getOffers(ids []int64) ([]Offer, error) {
 	return offersResponseBody.OffersSerialized, nil
 }
 
-func (p *Parser) GetOfferIDs() ([]int64, error) {
-	boundsList, err := geo.GetCellBoundsListByGeoJSON(p.geojson, p.searchCellSize)
+// DownloadPhoto downloads offer photo with parser's http client
+func (p *Parser) DownloadPhoto(photoURL string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, photoURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can't create request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	p.rateLimiter.Wait()
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("can't do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("can't read response body: %w", err)
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("server sent http error: %d", resp.StatusCode)
+	}
+
+	return respBody, nil
+}
+
+// retryServerErrors repeats request while server responds with 5xx, other errors are returned at once
+func retryServerErrors[T any](p *Parser, request func() (T, error)) (T, error) {
+	for attempt := 0; ; attempt++ {
+		result, err := request()
+		if err == nil || !errors.Is(err, errServerUnavailable) || attempt == serverErrorRetries {
+			return result, err
+		}
+
+		p.countStats(func(stats *Stats) { stats.Retries++ })
+	}
+}
+
+func (p *Parser) getCellClusters(bounds Bounds) (CellClusters, error) {
+	response, err := retryServerErrors(p, func() (*GetClustersResponseBody, error) {
+		return p.getClustersByBounds(bounds)
+	})
+	if err != nil {
+		return CellClusters{}, err
+	}
+
+	truncated := 0
+	for _, cluster := range response.Filtered {
+		if len(cluster.ClusterOfferIds) < cluster.Count {
+			truncated++
+		}
+	}
+	if truncated > 0 {
+		log.Printf("cell %v has %d truncated clusters, some offers are missed, decrease cell size", bounds, truncated)
+		p.countStats(func(stats *Stats) { stats.TruncatedClusters += truncated })
+	}
+
+	cell := CellClusters{
+		Bounds:               bounds,
+		Clusters:             response.Filtered,
+		NewbuildingsPolygons: response.NewbuildingsPolygons,
+	}
+
+	if p.checkpoint != nil {
+		err := p.checkpoint.SaveCell(cell)
+		if err != nil {
+			return CellClusters{}, fmt.Errorf("can't save cell checkpoint: %w", err)
+		}
+	}
+
+	return cell, nil
+}
+
+func (p *Parser) GetClusters() ([]CellClusters, error) {
+	boundsList, err := geo.GetGridCellBounds(p.polygon, p.grid)
 	if err != nil {
 		return nil, fmt.Errorf("can't get cell bounds list: %s", err)
 	}
 
-	cianBoundsList := make([]Bounds, len(boundsList))
+	cells := make([]CellClusters, len(boundsList))
+	pendingIndexes := make([]int, 0, len(boundsList))
+	pendingBoundsList := make([]Bounds, 0, len(boundsList))
 	for i := 0; i < len(boundsList); i++ {
-		cianBoundsList[i] = GeosBoundsToCianBounds(boundsList[i])
+		bounds := GeosBoundsToCianBounds(boundsList[i])
+
+		if p.checkpoint != nil {
+			if cell, ok := p.checkpoint.GetCell(bounds); ok {
+				cells[i] = cell
+				continue
+			}
+		}
+
+		pendingIndexes = append(pendingIndexes, i)
+		pendingBoundsList = append(pendingBoundsList, bounds)
 	}
 
-	workerPool := utils.NewWorkerPool(p.getClustersByBounds, p.maxWorkersCollectIDs)
+	if len(pendingBoundsList) < len(boundsList) {
+		log.Printf("%d of %d cells restored from checkpoint", len(boundsList)-len(pendingBoundsList), len(boundsList))
+	}
+
+	workerPool := utils.NewWorkerPool(p.getCellClusters, p.maxWorkersCollectIDs)
 	workerPool.OnProgress(func(current, total int) {
 		log.Printf("get clusters progress: %d%%\n", (current * 100 / total))
 	})
 
-	clustersList, err := workerPool.Map(context.Background(), cianBoundsList)
+	pendingCells, err := workerPool.Map(context.Background(), pendingBoundsList)
 	if err != nil {
 		return nil, fmt.Errorf("can't get clusters: %w", err)
 	}
 
-	offerIDs := make([]int64, 0)
-	for _, clusters := range clustersList {
-		for _, cluster := range clusters {
-			offerIDs = append(offerIDs, cluster.ClusterOfferIds...)
+	for i, cell := range pendingCells {
+		cells[pendingIndexes[i]] = cell
+	}
+
+	return cells, nil
+}
+
+func (p *Parser) getOffersBatch(ids []int64) ([]Offer, error) {
+	offers, err := retryServerErrors(p, func() ([]Offer, error) {
+		return p.getOffers(ids)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if p.checkpoint != nil {
+		err := p.checkpoint.SaveOffers(ids, offers)
+		if err != nil {
+			return nil, fmt.Errorf("can't save offers checkpoint: %w", err)
 		}
 	}
 
-	return utils.RemoveDuplicateInt64(offerIDs), nil
+	return offers, nil
 }
 
 func (p *Parser) GetOffers(ids []int64) ([]Offer, error) {
-	chunks := utils.Chunks(ids, 28)
+	offers := make([]Offer, 0)
 
-	workerPool := utils.NewWorkerPool(p.getOffers, p.maxWorkersCollectOffers)
+	if p.checkpoint != nil {
+		requestedIDs := make(map[int64]struct{}, len(ids))
+		for _, id := range ids {
+			requestedIDs[id] = struct{}{}
+		}
+
+		for _, offer := range p.checkpoint.GetFetchedOffers() {
+			if _, ok := requestedIDs[offer.CianID]; ok {
+				offers = append(offers, offer)
+			}
+		}
+
+		pendingIDs := make([]int64, 0, len(ids))
+		for _, id := range ids {
+			if !p.checkpoint.IsOfferFetched(id) {
+				pendingIDs = append(pendingIDs, id)
+			}
+		}
+
+		if len(pendingIDs) < len(ids) {
+			log.Printf("%d of %d offers restored from checkpoint", len(ids)-len(pendingIDs), len(ids))
+		}
+
+		ids = pendingIDs
+	}
+
+	if len(ids) == 0 {
+		return offers, nil
+	}
+
+	chunks := utils.Chunks(ids, OffersBatchSize)
+
+	workerPool := utils.NewWorkerPool(p.getOffersBatch, p.maxWorkersCollectOffers)
 	workerPool.OnProgress(func(current, total int) {
 		log.Printf("get offers progress: %d%%\n", (current * 100 / total))
 	})
@@ -308,7 +546,6 @@ func (p *Parser) GetOffers(ids []int64) ([]Offer, error) {
 		return nil, fmt.Errorf("can't get offers: %w", err)
 	}
 
-	offers := make([]Offer, 0)
 	for _, tmpOffers := range offersList {
 		offers = append(offers, tmpOffers...)
 	}
@@ -0,0 +1,90 @@
+package cian_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/cian/ciantest"
+	"github.com/mishannn/cianparser-go/internal/geo"
+)
+
+// syntheticFixturePath is a synthetic fixture of small area in Kazan, it is saved by Cassette
+// from ciantest server, so offers are generated and response shapes are not checked against Cian API
+const syntheticFixturePath = "testdata/synthetic_kazan.json"
+
+const fixtureArea = "49.100,55.780,49.115,55.790"
+
+func newFixtureParser(t *testing.T) *cian.Parser {
+	t.Helper()
+
+	fixture, err := ciantest.NewCassette(syntheticFixturePath, ciantest.CassetteReplay, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	geojson, err := geo.ReadArea(fixtureArea, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	polygon, _, err := geo.NormalizeGeoJSON(geojson)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpClient := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+		Transport: fixture,
+	}
+
+	return cian.NewParser(httpClient, "", polygon, "flatsale", nil, 1000, 1, 1)
+}
+
+func TestParserSyntheticFixture(t *testing.T) {
+	parser := newFixtureParser(t)
+
+	cells, err := parser.GetClusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := cian.GetOfferIDsFromCells(cells)
+	if len(ids) == 0 {
+		t.Fatal("no offer ids in clusters")
+	}
+
+	offers, err := parser.GetOffers(ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(offers) != len(ids) {
+		t.Errorf("got %d offers for %d ids", len(offers), len(ids))
+	}
+
+	bounds, err := geo.ParseBounds(fixtureArea)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, offer := range offers {
+		if offer.CianID == 0 || offer.Category == "" || offer.RoomsCount == 0 {
+			t.Errorf("offer %d is decoded without id, category or rooms count", offer.CianID)
+		}
+
+		if offer.BargainTerms.PriceRur <= 0 {
+			t.Errorf("offer %d has no price", offer.CianID)
+		}
+
+		if _, err := offer.GetTotalArea(); err != nil {
+			t.Errorf("offer %d: %s", offer.CianID, err)
+		}
+
+		if !bounds.ContainsPoint(offer.Geo.Coordinates.Lng, offer.Geo.Coordinates.Lat) {
+			t.Errorf("offer %d is outside of area", offer.CianID)
+		}
+	}
+}
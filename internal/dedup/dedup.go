@@ -0,0 +1,140 @@
+package dedup
+
+import (
+	"log"
+	"math"
+	"sort"
+
+	"github.com/paulmach/orb"
+	orbgeo "github.com/paulmach/orb/geo"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/geo"
+)
+
+type Params struct {
+	MaxDistanceMeters float64
+	MaxAreaDiff       float64
+	MaxPriceDiff      float64
+}
+
+type flatKey struct {
+	RoomsCount  int
+	FloorNumber int
+}
+
+type candidate struct {
+	index     int
+	point     orb.Point
+	totalArea float64
+	price     float64
+}
+
+func relativeDiff(a, b float64) float64 {
+	if a == b {
+		return 0
+	}
+	return math.Abs(a-b) / math.Max(math.Abs(a), math.Abs(b))
+}
+
+func (p Params) isDuplicate(a, b candidate) bool {
+	return orbgeo.Distance(a.point, b.point) <= p.MaxDistanceMeters &&
+		relativeDiff(a.totalArea, b.totalArea) <= p.MaxAreaDiff &&
+		relativeDiff(a.price, b.price) <= p.MaxPriceDiff
+}
+
+// GetDuplicateGroups returns group ID for every offer ID, group ID is the smallest offer ID in group,
+// offer joins group only if it is a duplicate of group representative, so similar offers
+// are not chained to one group, offers without coordinates are never grouped
+func GetDuplicateGroups(offers []cian.Offer, params Params) map[int64]int64 {
+	groupIndexes := make([]int, len(offers))
+	for i := range groupIndexes {
+		groupIndexes[i] = i
+	}
+
+	candidates := make(map[flatKey][]candidate)
+	for i, offer := range offers {
+		if offer.Geo.Coordinates.Lat == 0 && offer.Geo.Coordinates.Lng == 0 {
+			continue
+		}
+
+		totalArea, err := offer.GetTotalArea()
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+
+		key := flatKey{
+			RoomsCount:  offer.RoomsCount,
+			FloorNumber: offer.FloorNumber,
+		}
+
+		candidates[key] = append(candidates[key], candidate{
+			index:     i,
+			point:     orb.Point{offer.Geo.Coordinates.Lng, offer.Geo.Coordinates.Lat},
+			totalArea: totalArea,
+			price:     offer.BargainTerms.PriceRur,
+		})
+	}
+
+	maxLatDiff := params.MaxDistanceMeters / geo.MetersPerLatDegree
+	for _, list := range candidates {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].point.Lat() != list[j].point.Lat() {
+				return list[i].point.Lat() < list[j].point.Lat()
+			}
+			return offers[list[i].index].CianID < offers[list[j].index].CianID
+		})
+
+		// Representatives are added by latitude, so only the last ones close by latitude are checked
+		representatives := make([]candidate, 0)
+		for _, c := range list {
+			best := -1
+			bestDistance := math.Inf(1)
+			for r := len(representatives) - 1; r >= 0 && c.point.Lat()-representatives[r].point.Lat() <= maxLatDiff; r-- {
+				distance := orbgeo.Distance(c.point, representatives[r].point)
+				if distance < bestDistance && params.isDuplicate(representatives[r], c) {
+					best = r
+					bestDistance = distance
+				}
+			}
+
+			if best < 0 {
+				representatives = append(representatives, c)
+				continue
+			}
+
+			groupIndexes[c.index] = representatives[best].index
+		}
+	}
+
+	groupIDs := make(map[int]int64)
+	for i, offer := range offers {
+		if groupID, ok := groupIDs[groupIndexes[i]]; !ok || offer.CianID < groupID {
+			groupIDs[groupIndexes[i]] = offer.CianID
+		}
+	}
+
+	groups := make(map[int64]int64, len(offers))
+	for i, offer := range offers {
+		groups[offer.CianID] = groupIDs[groupIndexes[i]]
+	}
+
+	return groups
+}
+
+// Deduplicate returns one offer of every duplicate group
+func Deduplicate(offers []cian.Offer, groups map[int64]int64) []cian.Offer {
+	seen := make(map[int64]struct{})
+	dedupOffers := make([]cian.Offer, 0, len(offers))
+	for _, offer := range offers {
+		groupID := groups[offer.CianID]
+		if _, ok := seen[groupID]; ok {
+			continue
+		}
+		seen[groupID] = struct{}{}
+		dedupOffers = append(dedupOffers, offer)
+	}
+
+	return dedupOffers
+}
@@ -0,0 +1,123 @@
+package dedup
+
+import (
+	"testing"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+)
+
+var testParams = Params{
+	MaxDistanceMeters: 100,
+	MaxAreaDiff:       0.05,
+	MaxPriceDiff:      0.05,
+}
+
+func newOffer(id int64, lng, lat float64, totalArea string, price float64) cian.Offer {
+	return cian.Offer{
+		CianID:       id,
+		Geo:          cian.Geo{Coordinates: cian.Coordinates{Lng: lng, Lat: lat}},
+		RoomsCount:   2,
+		FloorNumber:  5,
+		TotalArea:    totalArea,
+		BargainTerms: cian.BargainTerms{PriceRur: price},
+	}
+}
+
+func TestGetDuplicateGroups(t *testing.T) {
+	tests := []struct {
+		name   string
+		offers []cian.Offer
+		want   map[int64]int64
+	}{
+		{
+			name: "close offers with the same flat",
+			offers: []cian.Offer{
+				newOffer(2, 49.1200, 55.7800, "50", 5000000),
+				newOffer(1, 49.1201, 55.7801, "50.5", 5100000),
+			},
+			want: map[int64]int64{1: 1, 2: 1},
+		},
+		{
+			name: "far offers",
+			offers: []cian.Offer{
+				newOffer(1, 49.1200, 55.7800, "50", 5000000),
+				newOffer(2, 49.1300, 55.7800, "50", 5000000),
+			},
+			want: map[int64]int64{1: 1, 2: 2},
+		},
+		{
+			name: "different price",
+			offers: []cian.Offer{
+				newOffer(1, 49.1200, 55.7800, "50", 5000000),
+				newOffer(2, 49.1200, 55.7800, "50", 6000000),
+			},
+			want: map[int64]int64{1: 1, 2: 2},
+		},
+		{
+			name: "different floor",
+			offers: []cian.Offer{
+				newOffer(1, 49.1200, 55.7800, "50", 5000000),
+				func() cian.Offer {
+					offer := newOffer(2, 49.1200, 55.7800, "50", 5000000)
+					offer.FloorNumber = 6
+					return offer
+				}(),
+			},
+			want: map[int64]int64{1: 1, 2: 2},
+		},
+		{
+			// every next offer is 80 m and 4% farther, first and last are out of every threshold
+			name: "chain is not merged",
+			offers: []cian.Offer{
+				newOffer(1, 49.12, 55.7800, "50", 5000000),
+				newOffer(2, 49.12, 55.7807, "52", 5200000),
+				newOffer(3, 49.12, 55.7814, "54", 5400000),
+			},
+			want: map[int64]int64{1: 1, 2: 1, 3: 3},
+		},
+		{
+			name: "offers without coordinates",
+			offers: []cian.Offer{
+				newOffer(1, 0, 0, "50", 5000000),
+				newOffer(2, 0, 0, "50", 5000000),
+			},
+			want: map[int64]int64{1: 1, 2: 2},
+		},
+		{
+			name: "offer with bad area",
+			offers: []cian.Offer{
+				newOffer(1, 49.12, 55.78, "", 5000000),
+				newOffer(2, 49.12, 55.78, "50", 5000000),
+			},
+			want: map[int64]int64{1: 1, 2: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := GetDuplicateGroups(tt.offers, testParams)
+			if len(groups) != len(tt.want) {
+				t.Fatalf("got %d groups, want %d", len(groups), len(tt.want))
+			}
+
+			for id, want := range tt.want {
+				if groups[id] != want {
+					t.Errorf("offer %d: got group %d, want %d", id, groups[id], want)
+				}
+			}
+		})
+	}
+}
+
+func TestDeduplicate(t *testing.T) {
+	offers := []cian.Offer{
+		newOffer(1, 49.12, 55.78, "50", 5000000),
+		newOffer(2, 49.12, 55.78, "50", 5000000),
+		newOffer(3, 49.13, 55.78, "50", 5000000),
+	}
+
+	dedupOffers := Deduplicate(offers, map[int64]int64{1: 1, 2: 1, 3: 3})
+	if len(dedupOffers) != 2 || dedupOffers[0].CianID != 1 || dedupOffers[1].CianID != 3 {
+		t.Errorf("got %v, want offers 1 and 3", dedupOffers)
+	}
+}
@@ -0,0 +1,82 @@
+package geo
+
+import (
+	"fmt"
+
+	"github.com/paulmach/orb/project"
+	"github.com/twpayne/go-geos"
+)
+
+// DensityPoint is a number of offers at point, usually a cluster from previous or coarse run
+type DensityPoint struct {
+	Lng   float64 `json:"lng"`
+	Lat   float64 `json:"lat"`
+	Count int     `json:"count"`
+}
+
+func getDensity(bounds *geos.Bounds, density []DensityPoint) int {
+	count := 0
+	for _, point := range density {
+		if bounds.ContainsPoint(point.Lng, point.Lat) {
+			count += point.Count
+		}
+	}
+
+	return count
+}
+
+// splitAdaptiveCell splits cell to quarters until it has no more than target offers
+// or its side becomes smaller than minCellSize
+func (g Grid) splitAdaptiveCell(bounds4326 *geos.Bounds, polygon *geos.PrepGeom, boundsList []*geos.Bounds) []*geos.Bounds {
+	if !polygon.Intersects(bounds4326.Geom()) {
+		return boundsList
+	}
+
+	bounds3857 := reprojectBounds(bounds4326, project.WGS84.ToMercator)
+	halfWidth := bounds3857.Width() / 2
+	halfHeight := bounds3857.Height() / 2
+
+	halfSize := max(halfWidth, halfHeight)
+	if !g.MercatorCellSize {
+		halfSize /= mercatorScale((bounds3857.MinY + bounds3857.MaxY) / 2)
+	}
+
+	if halfSize < g.MinCellSize || getDensity(bounds4326, g.Density) <= g.TargetOffers {
+		return append(boundsList, bounds4326)
+	}
+
+	midX := bounds3857.MinX + halfWidth
+	midY := bounds3857.MinY + halfHeight
+	quarters := []*geos.Bounds{
+		geos.NewBounds(bounds3857.MinX, midY, midX, bounds3857.MaxY),
+		geos.NewBounds(midX, midY, bounds3857.MaxX, bounds3857.MaxY),
+		geos.NewBounds(bounds3857.MinX, bounds3857.MinY, midX, midY),
+		geos.NewBounds(midX, bounds3857.MinY, bounds3857.MaxX, midY),
+	}
+
+	for _, quarter := range quarters {
+		boundsList = g.splitAdaptiveCell(reprojectBounds(quarter, project.Mercator.ToWGS84), polygon, boundsList)
+	}
+
+	return boundsList
+}
+
+// getAdaptiveCellBounds splits rect grid cells by density, so cell size is from MinCellSize to CellSize
+func (g Grid) getAdaptiveCellBounds(geom *geos.Geom) ([]*geos.Bounds, []string, error) {
+	if g.TargetOffers <= 0 {
+		return nil, nil, fmt.Errorf("target offers per cell must be positive")
+	}
+
+	if g.MinCellSize <= 0 || g.MinCellSize > g.CellSize {
+		return nil, nil, fmt.Errorf("min cell size must be positive and not bigger than cell size")
+	}
+
+	polygon := geom.Prepare()
+
+	boundsList := make([]*geos.Bounds, 0)
+	for _, bounds := range getCellBoundsByParts(geom, g.CellSize, g.MercatorCellSize) {
+		boundsList = g.splitAdaptiveCell(bounds, polygon, boundsList)
+	}
+
+	return boundsList, getBoundsIDs(boundsList), nil
+}
@@ -0,0 +1,97 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/twpayne/go-geos"
+)
+
+const testArea = "49.10,55.77,49.14,55.80"
+
+func getTestPolygon(t *testing.T) *geos.Geom {
+	t.Helper()
+
+	geojson, err := ReadArea(testArea, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	polygon, _, err := NormalizeGeoJSON(geojson)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return polygon
+}
+
+func TestAdaptiveGrid(t *testing.T) {
+	polygon := getTestPolygon(t)
+
+	rectCells, err := GetGridCells(polygon, Grid{Type: GridTypeRect, CellSize: 2000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dense := DensityPoint{Lng: 49.101, Lat: 55.771, Count: 1000}
+	sparse := DensityPoint{Lng: 49.139, Lat: 55.799, Count: 5}
+
+	tests := []struct {
+		name    string
+		density []DensityPoint
+		split   bool
+	}{
+		{"no density", nil, false},
+		{"sparse", []DensityPoint{sparse}, false},
+		{"dense corner", []DensityPoint{dense, sparse}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			grid := Grid{Type: GridTypeAdaptive, CellSize: 2000, MinCellSize: 250, TargetOffers: 20, Density: test.density}
+			cells, err := GetGridCells(polygon, grid)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !test.split {
+				if len(cells) != len(rectCells) {
+					t.Errorf("got %d cells, want %d cells of rect grid", len(cells), len(rectCells))
+				}
+				return
+			}
+
+			if len(cells) <= len(rectCells) {
+				t.Fatalf("got %d cells, want more than %d cells of rect grid", len(cells), len(rectCells))
+			}
+
+			for _, cell := range cells {
+				size := max(cell.WidthMeters, cell.HeightMeters)
+				if size < 250 {
+					t.Errorf("cell %s is %.0f m, smaller than min cell size", cell.ID, size)
+				}
+
+				if cell.Bounds.ContainsPoint(dense.Lng, dense.Lat) && size >= 500 {
+					t.Errorf("cell %s with dense point is %.0f m, want it split to min cell size", cell.ID, size)
+				}
+
+				if cell.Bounds.ContainsPoint(sparse.Lng, sparse.Lat) && getDensity(cell.Bounds, test.density) > grid.TargetOffers {
+					t.Errorf("cell %s with sparse point has more than target offers", cell.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestAdaptiveGridInvalid(t *testing.T) {
+	polygon := getTestPolygon(t)
+
+	for _, grid := range []Grid{
+		{Type: GridTypeAdaptive, CellSize: 2000, MinCellSize: 250},
+		{Type: GridTypeAdaptive, CellSize: 2000, TargetOffers: 20},
+		{Type: GridTypeAdaptive, CellSize: 2000, MinCellSize: 4000, TargetOffers: 20},
+	} {
+		if _, err := GetGridCells(polygon, grid); err == nil {
+			t.Errorf("grid %+v is accepted", grid)
+		}
+	}
+}
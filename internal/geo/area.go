@@ -0,0 +1,28 @@
+package geo
+
+import (
+	"github.com/twpayne/go-geos"
+)
+
+// Area checks if points are inside of target polygon
+type Area struct {
+	bounds   *geos.Bounds
+	prepared *geos.PrepGeom
+}
+
+// NewArea returns area of normalized polygon
+func NewArea(geom *geos.Geom) *Area {
+	return &Area{
+		bounds:   geom.Bounds(),
+		prepared: geom.Prepare(),
+	}
+}
+
+// Contains returns true for points inside of area or on its border
+func (a *Area) Contains(lng, lat float64) bool {
+	if !a.bounds.ContainsPoint(lng, lat) {
+		return false
+	}
+
+	return a.prepared.Intersects(geos.NewPointFromXY(lng, lat))
+}
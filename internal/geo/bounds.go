@@ -1,7 +1,6 @@
 package geo
 
 import (
-	"fmt"
 	"math"
 
 	"github.com/paulmach/orb"
@@ -78,11 +77,76 @@ func getCellBoundsByGeom(geom *geos.Geom, cellSize float64) []*geos.Bounds {
 	return cellBoundsList4326
 }
 
-func GetCellBoundsListByGeoJSON(geojson string, cellSize float64) ([]*geos.Bounds, error) {
-	geom, err := geos.NewGeomFromGeoJSON(geojson)
-	if err != nil {
-		return nil, fmt.Errorf("can't parse geojson: %w", err)
+// mercatorScale returns how many mercator units are in one ground meter at mercator y
+func mercatorScale(y float64) float64 {
+	lat := project.Mercator.ToWGS84(orb.Point{0, y})[1]
+	return 1 / math.Cos(lat*math.Pi/180)
+}
+
+// getGroundCellBoundsByGeom works as getCellBoundsByGeom but cell size is in ground meters,
+// mercator steps are corrected by scale factor at middle of each row
+func getGroundCellBoundsByGeom(geom *geos.Geom, cellSize float64) []*geos.Bounds {
+	bounds4326 := geom.Bounds()
+	bounds3857 := reprojectBounds(bounds4326, project.WGS84.ToMercator)
+	width := bounds3857.MaxX - bounds3857.MinX
+
+	// Ground length of latitude degree is constant, so rows are counted and spread evenly
+	// by latitude like columns, there is no sliver row at the end
+	height := bounds4326.MaxY - bounds4326.MinY
+	rows := max(1, int(math.Ceil(height*MetersPerLatDegree/cellSize)))
+	steplat := height / float64(rows)
+
+	cellBoundsList4326 := make([]*geos.Bounds, 0)
+
+	for row := 0; row < rows; row++ {
+		lat1 := bounds4326.MaxY - float64(row)*steplat
+		lat2 := bounds4326.MaxY - float64(row+1)*steplat
+		if row == rows-1 {
+			lat2 = bounds4326.MinY
+		}
+
+		y1 := project.WGS84.ToMercator(orb.Point{0, lat1})[1]
+		y2 := project.WGS84.ToMercator(orb.Point{0, lat2})[1]
+		if row == 0 {
+			y1 = bounds3857.MaxY
+		}
+		if row == rows-1 {
+			y2 = bounds3857.MinY
+		}
+
+		rowStep := cellSize * mercatorScale((y1+y2)/2)
+		cols := max(1, int(math.Ceil(width/rowStep)))
+		stepx := width / float64(cols)
+
+		// Columns are counted, accumulating steps can leave a sliver column at the end
+		for col := 0; col < cols; col++ {
+			x1 := bounds3857.MinX + float64(col)*stepx
+			x2 := bounds3857.MinX + float64(col+1)*stepx
+			if col == cols-1 {
+				x2 = bounds3857.MaxX
+			}
+
+			cellBounds4326 := reprojectBounds(geos.NewBounds(x1, y2, x2, y1), project.Mercator.ToWGS84)
+			if cellBounds4326.Geom().Intersects(geom) {
+				cellBoundsList4326 = append(cellBoundsList4326, cellBounds4326)
+			}
+		}
+	}
+
+	return cellBoundsList4326
+}
+
+// getCellBoundsByParts makes separate grid for each group of polygon parts,
+// mercatorCellSize keeps old behaviour with cell size in raw EPSG:3857 units
+func getCellBoundsByParts(geom *geos.Geom, cellSize float64, mercatorCellSize bool) []*geos.Bounds {
+	cellBoundsList := make([]*geos.Bounds, 0)
+	for _, group := range groupPolygonParts(geom, cellSize, mercatorCellSize) {
+		if mercatorCellSize {
+			cellBoundsList = append(cellBoundsList, getCellBoundsByGeom(group, cellSize)...)
+		} else {
+			cellBoundsList = append(cellBoundsList, getGroundCellBoundsByGeom(group, cellSize)...)
+		}
 	}
 
-	return getCellBoundsByGeom(geom, cellSize), nil
+	return cellBoundsList
 }
@@ -0,0 +1,35 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGroundCellBoundsRows(t *testing.T) {
+	polygon := getTestPolygon(t)
+
+	for _, cellSize := range []float64{300, 1000, 1500} {
+		cells, err := GetGridCells(polygon, Grid{Type: GridTypeRect, CellSize: cellSize})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rowHeights := make(map[float64]float64)
+		for _, cell := range cells {
+			rowHeights[cell.Bounds.MaxY] = cell.HeightMeters
+		}
+
+		bounds := polygon.Bounds()
+		wantRows := int(math.Ceil((bounds.MaxY - bounds.MinY) * MetersPerLatDegree / cellSize))
+		if len(rowHeights) != wantRows {
+			t.Errorf("%.0f m: got %d rows, want %d", cellSize, len(rowHeights), wantRows)
+		}
+
+		wantHeight := (bounds.MaxY - bounds.MinY) * MetersPerLatDegree / float64(wantRows)
+		for top, height := range rowHeights {
+			if math.Abs(height-wantHeight) > 1 {
+				t.Errorf("%.0f m: row at %f is %.1f m high, want %.1f m", cellSize, top, height, wantHeight)
+			}
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package geo
+
+import (
+	"github.com/paulmach/orb"
+	orbgeo "github.com/paulmach/orb/geo"
+	"github.com/twpayne/go-geos"
+)
+
+// Cell is a search grid cell with its ground size, geometry is a hexagon for h3 cells
+// and bounds for others
+type Cell struct {
+	Index        int
+	ID           string
+	Bounds       *geos.Bounds
+	Geometry     *geos.Geom
+	WidthMeters  float64
+	HeightMeters float64
+	Inside       bool
+}
+
+func newCell(index int, id string, bounds *geos.Bounds, geometry *geos.Geom, polygon *geos.PrepGeom) Cell {
+	midY := (bounds.MinY + bounds.MaxY) / 2
+	midX := (bounds.MinX + bounds.MaxX) / 2
+
+	return Cell{
+		Index:        index,
+		ID:           id,
+		Bounds:       bounds,
+		Geometry:     geometry,
+		WidthMeters:  orbgeo.Distance(orb.Point{bounds.MinX, midY}, orb.Point{bounds.MaxX, midY}),
+		HeightMeters: orbgeo.Distance(orb.Point{midX, bounds.MinY}, orb.Point{midX, bounds.MaxY}),
+		Inside:       polygon.Contains(geometry),
+	}
+}
+
+// CellsToFeatureCollection returns cells as features, properties can be extended by caller
+func CellsToFeatureCollection(cells []Cell) *FeatureCollection {
+	fc := NewFeatureCollection()
+	for _, cell := range cells {
+		fc.Add(cell.Geometry.ToGeoJSON(0), map[string]any{
+			"index":         cell.Index,
+			"id":            cell.ID,
+			"width_meters":  cell.WidthMeters,
+			"height_meters": cell.HeightMeters,
+			"inside":        cell.Inside,
+		})
+	}
+
+	return fc
+}
@@ -0,0 +1,78 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/twpayne/go-geos"
+)
+
+type district struct {
+	name     string
+	bounds   *geos.Bounds
+	prepared *geos.PrepGeom
+}
+
+// DistrictIndex finds feature of FeatureCollection which contains point
+type DistrictIndex struct {
+	districts []district
+}
+
+// NewDistrictIndex reads FeatureCollection, district name is taken from nameProperty of feature
+func NewDistrictIndex(geojson string, nameProperty string) (*DistrictIndex, error) {
+	var fc FeatureCollection
+	err := json.Unmarshal([]byte(geojson), &fc)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse geojson: %w", err)
+	}
+
+	if fc.Type != "FeatureCollection" {
+		return nil, errors.New("districts geojson must be a FeatureCollection")
+	}
+
+	index := &DistrictIndex{
+		districts: make([]district, 0, len(fc.Features)),
+	}
+
+	for i, feature := range fc.Features {
+		name, ok := feature.Properties[nameProperty]
+		if !ok {
+			return nil, fmt.Errorf("feature %d has no property '%s'", i, nameProperty)
+		}
+
+		geom, err := geos.NewGeomFromGeoJSON(string(feature.Geometry))
+		if err != nil {
+			return nil, fmt.Errorf("can't parse geometry of feature %d: %w", i, err)
+		}
+
+		index.districts = append(index.districts, district{
+			name:     fmt.Sprint(name),
+			bounds:   geom.Bounds(),
+			prepared: geom.Prepare(),
+		})
+	}
+
+	return index, nil
+}
+
+// Locate returns name of the first district which contains point
+func (di *DistrictIndex) Locate(lng, lat float64) (string, bool) {
+	var point *geos.Geom
+
+	for _, district := range di.districts {
+		if !district.bounds.ContainsPoint(lng, lat) {
+			continue
+		}
+
+		if point == nil {
+			point = geos.NewPointFromXY(lng, lat)
+		}
+
+		if district.prepared.Intersects(point) {
+			return district.name, true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,36 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type Feature struct {
+	Type       string          `json:"type"`
+	Properties map[string]any  `json:"properties"`
+	Geometry   json.RawMessage `json:"geometry"`
+}
+
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+func NewFeatureCollection() *FeatureCollection {
+	return &FeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]Feature, 0),
+	}
+}
+
+func (fc *FeatureCollection) Add(geometryGeoJSON string, properties map[string]any) {
+	fc.Features = append(fc.Features, Feature{
+		Type:       "Feature",
+		Properties: properties,
+		Geometry:   json.RawMessage(geometryGeoJSON),
+	})
+}
+
+func (fc *FeatureCollection) AddPoint(lng, lat float64, properties map[string]any) {
+	fc.Add(fmt.Sprintf(`{"type":"Point","coordinates":[%v,%v]}`, lng, lat), properties)
+}
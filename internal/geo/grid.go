@@ -0,0 +1,169 @@
+package geo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mishannn/cianparser-go/internal/h3"
+	"github.com/mmcloughlin/geohash"
+	"github.com/twpayne/go-geos"
+)
+
+const (
+	GridTypeRect     = "rect"
+	GridTypeGeohash  = "geohash"
+	GridTypeH3       = "h3"
+	GridTypeAdaptive = "adaptive"
+)
+
+const maxGeohashPrecision = 12
+
+// boundsIDPrecision is a number of degree decimals in rect cell ids, it is about 10 cm
+const boundsIDPrecision = 6
+
+// Grid describes how polygon is split to cells, rect cells depend on polygon bounds
+// while geohash and h3 cells have the same ids in every run and city, adaptive cells are rect cells
+// split by offers density, h3 cells are searched by their bounds
+type Grid struct {
+	Type     string
+	CellSize float64
+	// MercatorCellSize makes cell size raw EPSG:3857 units instead of ground meters
+	MercatorCellSize bool
+	GeohashPrecision int
+	H3Resolution     int
+
+	Density      []DensityPoint
+	TargetOffers int
+	MinCellSize  float64
+}
+
+// CellID returns id of cell with point, only geohash and h3 grids can compute it without polygon
+func (g Grid) CellID(lng, lat float64) (string, bool) {
+	switch g.Type {
+	case GridTypeGeohash:
+		return geohash.EncodeWithPrecision(lat, lng, uint(g.GeohashPrecision)), true
+	case GridTypeH3:
+		cell, err := h3.LatLngToCell(lat, lng, g.H3Resolution)
+		if err != nil {
+			return "", false
+		}
+
+		return cell.String(), true
+	default:
+		return "", false
+	}
+}
+
+// getCellGeom returns cell polygon, it is a hexagon for h3 cells and bounds for others
+func (g Grid) getCellGeom(id string, bounds *geos.Bounds) *geos.Geom {
+	if g.Type == GridTypeH3 {
+		cell, err := h3.ParseCell(id)
+		if err == nil {
+			return getH3CellGeom(cell)
+		}
+	}
+
+	return bounds.Geom()
+}
+
+func (g Grid) getCellBounds(geom *geos.Geom) ([]*geos.Bounds, []string, error) {
+	switch g.Type {
+	case "", GridTypeRect:
+		boundsList := getCellBoundsByParts(geom, g.CellSize, g.MercatorCellSize)
+		return boundsList, getBoundsIDs(boundsList), nil
+	case GridTypeGeohash:
+		if g.GeohashPrecision < 1 || g.GeohashPrecision > maxGeohashPrecision {
+			return nil, nil, fmt.Errorf("geohash precision must be from 1 to %d", maxGeohashPrecision)
+		}
+
+		boundsList, ids := getGeohashCellBounds(geom, uint(g.GeohashPrecision))
+		return boundsList, ids, nil
+	case GridTypeH3:
+		if g.H3Resolution < 0 || g.H3Resolution > h3.MaxResolution {
+			return nil, nil, fmt.Errorf("h3 resolution must be from 0 to %d", h3.MaxResolution)
+		}
+
+		return getH3CellBounds(geom, g.H3Resolution)
+	case GridTypeAdaptive:
+		return g.getAdaptiveCellBounds(geom)
+	default:
+		return nil, nil, fmt.Errorf("unknown grid type: %s", g.Type)
+	}
+}
+
+// getBoundsIDs returns ids of rect cells made of their bounds, so cell has the same id
+// in every run with the same polygon and cell size
+func getBoundsIDs(boundsList []*geos.Bounds) []string {
+	ids := make([]string, len(boundsList))
+	for i, bounds := range boundsList {
+		ids[i] = strings.Join([]string{
+			strconv.FormatFloat(bounds.MinX, 'f', boundsIDPrecision, 64),
+			strconv.FormatFloat(bounds.MinY, 'f', boundsIDPrecision, 64),
+			strconv.FormatFloat(bounds.MaxX, 'f', boundsIDPrecision, 64),
+			strconv.FormatFloat(bounds.MaxY, 'f', boundsIDPrecision, 64),
+		}, ",")
+	}
+
+	return ids
+}
+
+// getGeohashCellBounds returns geohash boxes intersecting geom from south-west to north-east
+func getGeohashCellBounds(geom *geos.Geom, precision uint) ([]*geos.Bounds, []string) {
+	bounds := geom.Bounds()
+	polygon := geom.Prepare()
+
+	start := geohash.BoundingBox(geohash.EncodeWithPrecision(bounds.MinY, bounds.MinX, precision))
+	height := start.MaxLat - start.MinLat
+	width := start.MaxLng - start.MinLng
+
+	boundsList := make([]*geos.Bounds, 0)
+	ids := make([]string, 0)
+
+	for row := 0; start.MinLat+float64(row)*height < bounds.MaxY; row++ {
+		lat := start.MinLat + (float64(row)+0.5)*height
+
+		for col := 0; start.MinLng+float64(col)*width < bounds.MaxX; col++ {
+			lng := start.MinLng + (float64(col)+0.5)*width
+
+			// Encode box center to get rid of float errors on box borders
+			id := geohash.EncodeWithPrecision(lat, lng, precision)
+			box := geohash.BoundingBox(id)
+			cellBounds := geos.NewBounds(box.MinLng, box.MinLat, box.MaxLng, box.MaxLat)
+
+			if polygon.Intersects(cellBounds.Geom()) {
+				boundsList = append(boundsList, cellBounds)
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return boundsList, ids
+}
+
+// GetGridCellBounds returns bounds of grid cells intersecting normalized polygon
+func GetGridCellBounds(geom *geos.Geom, grid Grid) ([]*geos.Bounds, error) {
+	boundsList, _, err := grid.getCellBounds(geom)
+	if err != nil {
+		return nil, fmt.Errorf("can't get grid cells: %w", err)
+	}
+
+	return boundsList, nil
+}
+
+// GetGridCells returns grid cells intersecting normalized polygon with their ids and sizes
+func GetGridCells(geom *geos.Geom, grid Grid) ([]Cell, error) {
+	boundsList, ids, err := grid.getCellBounds(geom)
+	if err != nil {
+		return nil, fmt.Errorf("can't get grid cells: %w", err)
+	}
+
+	polygon := geom.Prepare()
+
+	cells := make([]Cell, len(boundsList))
+	for i, bounds := range boundsList {
+		cells[i] = newCell(i, ids[i], bounds, grid.getCellGeom(ids[i], bounds), polygon)
+	}
+
+	return cells, nil
+}
@@ -0,0 +1,113 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/mishannn/cianparser-go/internal/h3"
+	"github.com/paulmach/orb"
+	orbgeo "github.com/paulmach/orb/geo"
+	"github.com/twpayne/go-geos"
+)
+
+// maxH3Samples limits number of points sampled to find h3 cells of polygon
+const maxH3Samples = 10000000
+
+// getH3CellGeom returns hexagon of h3 cell
+func getH3CellGeom(cell h3.Cell) *geos.Geom {
+	ring := cell.Boundary()
+
+	coords := make([][]float64, len(ring))
+	for i, point := range ring {
+		coords[i] = []float64{point[0], point[1]}
+	}
+
+	return geos.NewPolygon([][][]float64{coords})
+}
+
+// getH3EdgeMeters returns shortest edge of h3 cell at point
+func getH3EdgeMeters(lng, lat float64, res int) (float64, error) {
+	cell, err := h3.LatLngToCell(lat, lng, res)
+	if err != nil {
+		return 0, err
+	}
+
+	ring := cell.Boundary()
+
+	edge := math.Inf(1)
+	for i := 1; i < len(ring); i++ {
+		edge = min(edge, orbgeo.Distance(ring[i-1], ring[i]))
+	}
+
+	return edge, nil
+}
+
+// getH3CellBounds returns bounds of h3 cells intersecting geom sorted by id, cells are found
+// by sampling points with step of half of cell edge around geom bounds
+func getH3CellBounds(geom *geos.Geom, res int) ([]*geos.Bounds, []string, error) {
+	bounds := geom.Bounds()
+	polygon := geom.Prepare()
+
+	center := orb.Point{(bounds.MinX + bounds.MaxX) / 2, (bounds.MinY + bounds.MaxY) / 2}
+	edge, err := getH3EdgeMeters(center[0], center[1], res)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// step is a half of cell edge and bounds are extended by two cell edges,
+	// so cells which only touch bounds are sampled too
+	const margin = 4
+
+	latStep := orbgeo.PointAtBearingAndDistance(center, 0, edge/2)[1] - center[1]
+	minLat := bounds.MinY - margin*latStep
+	maxLat := bounds.MaxY + margin*latStep
+
+	// longitude degree is the longest closest to equator
+	equatorLat := 0.0
+	if minLat > 0 || maxLat < 0 {
+		equatorLat = min(math.Abs(minLat), math.Abs(maxLat))
+	}
+	lngStep := latStep / math.Cos(equatorLat*math.Pi/180)
+	minLng := bounds.MinX - margin*lngStep
+	maxLng := bounds.MaxX + margin*lngStep
+
+	rows := int(math.Ceil((maxLat - minLat) / latStep))
+	cols := int(math.Ceil((maxLng - minLng) / lngStep))
+	if (rows+1)*(cols+1) > maxH3Samples {
+		return nil, nil, fmt.Errorf("h3 resolution %d is too fine for polygon", res)
+	}
+
+	cells := make(map[h3.Cell]bool)
+	for row := 0; row <= rows; row++ {
+		lat := minLat + float64(row)*latStep
+
+		for col := 0; col <= cols; col++ {
+			lng := minLng + float64(col)*lngStep
+
+			cell, err := h3.LatLngToCell(lat, lng, res)
+			if err != nil {
+				return nil, nil, err
+			}
+			cells[cell] = true
+		}
+	}
+
+	ids := make([]string, 0)
+	geoms := make(map[string]*geos.Geom)
+	for cell := range cells {
+		cellGeom := getH3CellGeom(cell)
+		if polygon.Intersects(cellGeom) {
+			ids = append(ids, cell.String())
+			geoms[cell.String()] = cellGeom
+		}
+	}
+	sort.Strings(ids)
+
+	boundsList := make([]*geos.Bounds, len(ids))
+	for i, id := range ids {
+		boundsList[i] = geoms[id].Bounds()
+	}
+
+	return boundsList, ids, nil
+}
@@ -0,0 +1,280 @@
+package geo
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/project"
+	"github.com/twpayne/go-geos"
+	"gonum.org/v1/gonum/stat"
+)
+
+const (
+	HeatmapShapeRect = "rect"
+	HeatmapShapeHex  = "hex"
+)
+
+const rasterNoData = -9999
+
+// RasterProjectionWKT is EPSG:3857 in ESRI WKT, it is written to .prj file next to ASCII grid
+const RasterProjectionWKT = `PROJCS["WGS_1984_Web_Mercator_Auxiliary_Sphere",GEOGCS["GCS_WGS_1984",DATUM["D_WGS_1984",SPHEROID["WGS_1984",6378137.0,298.257223563]],PRIMEM["Greenwich",0.0],UNIT["Degree",0.0174532925199433]],PROJECTION["Mercator_Auxiliary_Sphere"],PARAMETER["False_Easting",0.0],PARAMETER["False_Northing",0.0],PARAMETER["Central_Meridian",0.0],PARAMETER["Standard_Parallel_1",0.0],PARAMETER["Auxiliary_Sphere_Type",0.0],UNIT["Meter",1.0]]`
+
+// HeatmapPoint is a value at point, e.g. offer price per meter
+type HeatmapPoint struct {
+	Lng   float64
+	Lat   float64
+	Value float64
+}
+
+// HeatmapCell has median value of points in cell, quantile is a share of cells
+// with median not bigger than this cell has
+type HeatmapCell struct {
+	ID       string
+	Geometry *geos.Geom
+	Count    int
+	Median   float64
+	Quantile float64
+}
+
+type binKey [2]int
+
+// binner splits mercator plane to cells of fixed shape
+type binner interface {
+	key(x, y float64) binKey
+	ring(key binKey) [][]float64
+}
+
+type rectBinner struct {
+	minX float64
+	minY float64
+	step float64
+}
+
+func (b rectBinner) key(x, y float64) binKey {
+	return binKey{int(math.Floor((x - b.minX) / b.step)), int(math.Floor((y - b.minY) / b.step))}
+}
+
+func (b rectBinner) ring(key binKey) [][]float64 {
+	x1 := b.minX + float64(key[0])*b.step
+	y1 := b.minY + float64(key[1])*b.step
+	x2 := x1 + b.step
+	y2 := y1 + b.step
+
+	return [][]float64{{x1, y1}, {x2, y1}, {x2, y2}, {x1, y2}, {x1, y1}}
+}
+
+// hexBinner uses pointy-top hexagons in axial coordinates, step is a distance between opposite sides
+type hexBinner struct {
+	minX   float64
+	minY   float64
+	radius float64
+}
+
+func (b hexBinner) key(x, y float64) binKey {
+	x -= b.minX
+	y -= b.minY
+
+	q := (math.Sqrt(3)/3*x - y/3) / b.radius
+	r := (2.0 / 3 * y) / b.radius
+	s := -q - r
+
+	rq, rr, rs := math.Round(q), math.Round(r), math.Round(s)
+	dq, dr, ds := math.Abs(rq-q), math.Abs(rr-r), math.Abs(rs-s)
+
+	if dq > dr && dq > ds {
+		rq = -rr - rs
+	} else if dr > ds {
+		rr = -rq - rs
+	}
+
+	return binKey{int(rq), int(rr)}
+}
+
+func (b hexBinner) ring(key binKey) [][]float64 {
+	q, r := float64(key[0]), float64(key[1])
+	cx := b.minX + b.radius*(math.Sqrt(3)*q+math.Sqrt(3)/2*r)
+	cy := b.minY + b.radius*(1.5*r)
+
+	ring := make([][]float64, 0, 7)
+	for i := 0; i <= 6; i++ {
+		angle := math.Pi / 180 * float64(30+60*(i%6))
+		ring = append(ring, []float64{cx + b.radius*math.Cos(angle), cy + b.radius*math.Sin(angle)})
+	}
+
+	return ring
+}
+
+// newBinner returns binner with cells of sizeMeters on the ground at area center
+func newBinner(shape string, bounds3857 *geos.Bounds, sizeMeters float64) (binner, error) {
+	step := sizeMeters * mercatorScale((bounds3857.MinY+bounds3857.MaxY)/2)
+
+	switch shape {
+	case HeatmapShapeRect:
+		return rectBinner{minX: bounds3857.MinX, minY: bounds3857.MinY, step: step}, nil
+	case HeatmapShapeHex:
+		return hexBinner{minX: bounds3857.MinX, minY: bounds3857.MinY, radius: step / math.Sqrt(3)}, nil
+	default:
+		return nil, fmt.Errorf("unknown heatmap shape: %s", shape)
+	}
+}
+
+// binPoints groups values of points inside of area by cells
+func binPoints(b binner, area *geos.PrepGeom, points []HeatmapPoint) map[binKey][]float64 {
+	bins := make(map[binKey][]float64)
+	for _, point := range points {
+		if !area.Intersects(geos.NewPointFromXY(point.Lng, point.Lat)) {
+			continue
+		}
+
+		p := project.WGS84.ToMercator(orb.Point{point.Lng, point.Lat})
+		key := b.key(p[0], p[1])
+		bins[key] = append(bins[key], point.Value)
+	}
+
+	return bins
+}
+
+// BuildHeatmap returns cells with points clipped to normalized area, cells without points are skipped
+func BuildHeatmap(geom *geos.Geom, shape string, sizeMeters float64, points []HeatmapPoint) ([]HeatmapCell, error) {
+	b, err := newBinner(shape, reprojectBounds(geom.Bounds(), project.WGS84.ToMercator), sizeMeters)
+	if err != nil {
+		return nil, err
+	}
+
+	bins := binPoints(b, geom.Prepare(), points)
+
+	keys := make([]binKey, 0, len(bins))
+	for key := range bins {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][1] > keys[j][1] || keys[i][1] == keys[j][1] && keys[i][0] < keys[j][0]
+	})
+
+	cells := make([]HeatmapCell, 0, len(keys))
+	medians := make([]float64, 0, len(keys))
+	for _, key := range keys {
+		values := bins[key]
+		sort.Float64s(values)
+
+		ring := b.ring(key)
+		for _, coord := range ring {
+			p := project.Mercator.ToWGS84(orb.Point{coord[0], coord[1]})
+			coord[0], coord[1] = p[0], p[1]
+		}
+
+		median := stat.Quantile(0.5, stat.Empirical, values, nil)
+		cells = append(cells, HeatmapCell{
+			ID:       fmt.Sprintf("%d_%d", key[0], key[1]),
+			Geometry: geos.NewPolygon([][][]float64{ring}).Intersection(geom),
+			Count:    len(values),
+			Median:   median,
+		})
+		medians = append(medians, median)
+	}
+
+	sort.Float64s(medians)
+	for i := range cells {
+		cells[i].Quantile = stat.CDF(cells[i].Median, stat.Empirical, medians, nil)
+	}
+
+	return cells, nil
+}
+
+// HeatmapToFeatureCollection returns cells as features with value, count and quantile
+func HeatmapToFeatureCollection(cells []HeatmapCell) *FeatureCollection {
+	fc := NewFeatureCollection()
+	for _, cell := range cells {
+		fc.Add(cell.Geometry.ToGeoJSON(0), map[string]any{
+			"id":       cell.ID,
+			"value":    cell.Median,
+			"count":    cell.Count,
+			"quantile": cell.Quantile,
+		})
+	}
+
+	return fc
+}
+
+// WriteHeatmapASCIIGrid writes median values as ESRI ASCII grid in EPSG:3857 coordinates,
+// cells with center outside of area or without points have no data
+func WriteHeatmapASCIIGrid(w io.Writer, geom *geos.Geom, sizeMeters float64, points []HeatmapPoint) error {
+	bounds3857 := reprojectBounds(geom.Bounds(), project.WGS84.ToMercator)
+	b, err := newBinner(HeatmapShapeRect, bounds3857, sizeMeters)
+	if err != nil {
+		return err
+	}
+	rect := b.(rectBinner)
+
+	cols := max(1, int(math.Ceil((bounds3857.MaxX-bounds3857.MinX)/rect.step)))
+	rows := max(1, int(math.Ceil((bounds3857.MaxY-bounds3857.MinY)/rect.step)))
+
+	// Points on east and north edges of area are out of the last column and row, so they are clamped
+	area := geom.Prepare()
+	bins := make(map[binKey][]float64)
+	for key, values := range binPoints(rect, area, points) {
+		key = binKey{min(key[0], cols-1), min(key[1], rows-1)}
+		bins[key] = append(bins[key], values...)
+	}
+
+	_, err = fmt.Fprintf(w, "ncols %d\nnrows %d\nxllcorner %f\nyllcorner %f\ncellsize %f\nNODATA_value %d\n",
+		cols, rows, rect.minX, rect.minY, rect.step, rasterNoData)
+	if err != nil {
+		return fmt.Errorf("can't write grid header: %w", err)
+	}
+
+	// Rows go from north to south
+	for row := rows - 1; row >= 0; row-- {
+		for col := 0; col < cols; col++ {
+			value := float64(rasterNoData)
+
+			values, ok := bins[binKey{col, row}]
+			center := project.Mercator.ToWGS84(orb.Point{
+				rect.minX + (float64(col)+0.5)*rect.step,
+				rect.minY + (float64(row)+0.5)*rect.step,
+			})
+			if ok && area.Intersects(geos.NewPointFromXY(center[0], center[1])) {
+				sort.Float64s(values)
+				value = stat.Quantile(0.5, stat.Empirical, values, nil)
+			}
+
+			separator := " "
+			if col == cols-1 {
+				separator = "\n"
+			}
+
+			_, err = fmt.Fprintf(w, "%.0f%s", value, separator)
+			if err != nil {
+				return fmt.Errorf("can't write grid row: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetGridNodes returns centers of square cells of sizeMeters which are inside of area
+func GetGridNodes(geom *geos.Geom, sizeMeters float64) ([]orb.Point, error) {
+	bounds3857 := reprojectBounds(geom.Bounds(), project.WGS84.ToMercator)
+	b, err := newBinner(HeatmapShapeRect, bounds3857, sizeMeters)
+	if err != nil {
+		return nil, err
+	}
+	rect := b.(rectBinner)
+
+	area := geom.Prepare()
+	nodes := make([]orb.Point, 0)
+	for y := rect.minY + rect.step/2; y < bounds3857.MaxY; y += rect.step {
+		for x := rect.minX + rect.step/2; x < bounds3857.MaxX; x += rect.step {
+			node := project.Mercator.ToWGS84(orb.Point{x, y})
+			if area.Intersects(geos.NewPointFromXY(node[0], node[1])) {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+
+	return nodes, nil
+}
@@ -0,0 +1,221 @@
+package geo
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/project"
+	"github.com/twpayne/go-geos"
+)
+
+func TestRectBinner(t *testing.T) {
+	b := rectBinner{minX: 100, minY: 200, step: 10}
+
+	tests := []struct {
+		x, y float64
+		want binKey
+	}{
+		{100, 200, binKey{0, 0}},
+		{109.99, 219.99, binKey{0, 1}},
+		{110, 200, binKey{1, 0}},
+		{99.9, 200, binKey{-1, 0}},
+	}
+
+	for _, test := range tests {
+		if got := b.key(test.x, test.y); got != test.want {
+			t.Errorf("%f, %f: got key %v, want %v", test.x, test.y, got, test.want)
+		}
+	}
+
+	ring := b.ring(binKey{1, 2})
+	want := [][]float64{{110, 220}, {120, 220}, {120, 230}, {110, 230}, {110, 220}}
+	for i := range want {
+		if ring[i][0] != want[i][0] || ring[i][1] != want[i][1] {
+			t.Fatalf("got ring %v, want %v", ring, want)
+		}
+	}
+}
+
+func TestHexBinner(t *testing.T) {
+	step := 100.0
+	b := hexBinner{minX: 1000, minY: 2000, radius: step / math.Sqrt(3)}
+
+	for q := -3; q <= 3; q++ {
+		for r := -3; r <= 3; r++ {
+			key := binKey{q, r}
+			ring := b.ring(key)
+
+			if len(ring) != 7 || ring[0][0] != ring[6][0] || ring[0][1] != ring[6][1] {
+				t.Fatalf("%v: ring %v is not closed hexagon", key, ring)
+			}
+
+			cx, cy := 0.0, 0.0
+			for _, vertex := range ring[:6] {
+				cx += vertex[0] / 6
+				cy += vertex[1] / 6
+			}
+
+			if got := b.key(cx, cy); got != key {
+				t.Errorf("center of %v is binned to %v", key, got)
+			}
+
+			for _, vertex := range ring[:6] {
+				x, y := cx+(vertex[0]-cx)*0.95, cy+(vertex[1]-cy)*0.95
+				if got := b.key(x, y); got != key {
+					t.Errorf("point near vertex of %v is binned to %v", key, got)
+				}
+			}
+
+			// distance between opposite sides is a step
+			east := b.ring(binKey{q + 1, r})
+			if distance := (east[0][0] - ring[0][0]); math.Abs(distance-step) > 1e-9 {
+				t.Errorf("%v: neighbour is %f away, want %f", key, distance, step)
+			}
+		}
+	}
+}
+
+func TestBuildHeatmapQuantiles(t *testing.T) {
+	polygon := getTestPolygon(t)
+
+	points := []HeatmapPoint{
+		{Lng: 49.101, Lat: 55.771, Value: 1},
+		{Lng: 49.10101, Lat: 55.77101, Value: 3},
+		{Lng: 49.10102, Lat: 55.77102, Value: 2},
+		{Lng: 49.139, Lat: 55.799, Value: 10},
+		{Lng: 49.12, Lat: 55.785, Value: 5},
+		{Lng: 49.12001, Lat: 55.78501, Value: 7},
+		// outside of area
+		{Lng: 49.2, Lat: 55.785, Value: 100},
+	}
+
+	for _, shape := range []string{HeatmapShapeRect, HeatmapShapeHex} {
+		t.Run(shape, func(t *testing.T) {
+			cells, err := BuildHeatmap(polygon, shape, 1000, points)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			type result struct {
+				count    int
+				quantile float64
+			}
+			got := make(map[float64]result)
+			for _, cell := range cells {
+				got[cell.Median] = result{cell.Count, cell.Quantile}
+			}
+
+			want := map[float64]result{
+				2:  {3, 1.0 / 3},
+				5:  {2, 2.0 / 3},
+				10: {1, 1},
+			}
+			if len(got) != len(want) {
+				t.Fatalf("got cells %v, want %v", got, want)
+			}
+			for median, w := range want {
+				if g := got[median]; g.count != w.count || math.Abs(g.quantile-w.quantile) > 1e-9 {
+					t.Errorf("median %f: got %d points with quantile %f, want %d with %f", median, g.count, g.quantile, w.count, w.quantile)
+				}
+			}
+		})
+	}
+
+	if _, err := BuildHeatmap(polygon, "triangle", 1000, points); err == nil {
+		t.Error("unknown shape is accepted")
+	}
+}
+
+// readASCIIGrid returns header values and rows of ESRI ASCII grid
+func readASCIIGrid(t *testing.T, data []byte) (map[string]float64, [][]float64) {
+	t.Helper()
+
+	header := make(map[string]float64)
+	rows := make([][]float64, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 0; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if line < 6 {
+			value, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			header[fields[0]] = value
+			continue
+		}
+
+		row := make([]float64, 0, len(fields))
+		for _, field := range fields {
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			row = append(row, value)
+		}
+		rows = append(rows, row)
+	}
+
+	return header, rows
+}
+
+func TestWriteHeatmapASCIIGrid(t *testing.T) {
+	sw := project.Mercator.ToWGS84(orb.Point{5465000, 7515000})
+	ne := project.Mercator.ToWGS84(orb.Point{5468000, 7518000})
+	polygon := geos.NewBounds(sw[0], sw[1], ne[0], ne[1]).Geom()
+
+	// size which makes area exactly two columns wide, so points on east edge are at column 2
+	bounds3857 := reprojectBounds(polygon.Bounds(), project.WGS84.ToMercator)
+	width := bounds3857.MaxX - bounds3857.MinX
+	scale := mercatorScale((bounds3857.MinY + bounds3857.MaxY) / 2)
+	size := width / 2 / scale
+	for i := 0; i < 100 && width/(size*scale) != 2; i++ {
+		size = math.Nextafter(size, math.Inf(1))
+	}
+	if width/(size*scale) != 2 {
+		t.Fatal("can't find size of exactly two columns")
+	}
+
+	points := []HeatmapPoint{
+		{Lng: sw[0] + 1e-6, Lat: sw[1] + 1e-6, Value: 100},
+		{Lng: sw[0] + 2e-6, Lat: sw[1] + 2e-6, Value: 300},
+		{Lng: sw[0] + 3e-6, Lat: sw[1] + 3e-6, Value: 200},
+		// east edge
+		{Lng: ne[0], Lat: sw[1] + 1e-6, Value: 42},
+	}
+
+	var buf bytes.Buffer
+	err := WriteHeatmapASCIIGrid(&buf, polygon, size, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header, rows := readASCIIGrid(t, buf.Bytes())
+	if header["ncols"] != 2 || header["NODATA_value"] != rasterNoData {
+		t.Fatalf("got header %v", header)
+	}
+	if math.Abs(header["xllcorner"]-bounds3857.MinX) > 1e-6 || math.Abs(header["yllcorner"]-bounds3857.MinY) > 1e-6 {
+		t.Errorf("got lower left corner %f, %f, want %f, %f", header["xllcorner"], header["yllcorner"], bounds3857.MinX, bounds3857.MinY)
+	}
+	if len(rows) != int(header["nrows"]) {
+		t.Fatalf("got %d rows, want %d", len(rows), int(header["nrows"]))
+	}
+
+	// rows go from north to south
+	south := rows[len(rows)-1]
+	if south[0] != 200 || south[1] != 42 {
+		t.Errorf("got south row %v, want median 200 and east edge point 42", south)
+	}
+
+	for _, row := range rows[:len(rows)-1] {
+		for _, value := range row {
+			if value != rasterNoData {
+				t.Errorf("got value %f in row without points", value)
+			}
+		}
+	}
+}
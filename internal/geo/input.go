@@ -0,0 +1,209 @@
+package geo
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/twpayne/go-geos"
+)
+
+var wktPrefixes = []string{"POLYGON", "MULTIPOLYGON", "GEOMETRYCOLLECTION", "SRID="}
+
+// ReadArea returns GeoJSON of area, input is a preset name, a "minLon,minLat,maxLon,maxLat" bbox
+// or a path to GeoJSON, KML, WKT or WKB file, format of file is detected by its content
+func ReadArea(input string, presets fs.FS) (string, error) {
+	data, err := os.ReadFile(input)
+	if err == nil {
+		return parseAreaFile(data)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("can't read area file: %w", err)
+	}
+
+	if presets != nil {
+		data, err := fs.ReadFile(presets, input+".geojson")
+		if err == nil {
+			return string(data), nil
+		}
+	}
+
+	if strings.Count(input, ",") == 3 {
+		return parseBbox(input)
+	}
+
+	return "", fmt.Errorf("%s is not a file, preset name or bbox", input)
+}
+
+func parseAreaFile(data []byte) (string, error) {
+	text := bytes.TrimSpace(data)
+	if len(text) == 0 {
+		return "", fmt.Errorf("area file is empty")
+	}
+
+	switch {
+	case text[0] == '{':
+		return string(text), nil
+	case text[0] == '<':
+		return parseKML(text)
+	case hasWKTPrefix(text):
+		geom, err := geos.NewGeomFromWKT(string(text))
+		if err != nil {
+			return "", fmt.Errorf("can't parse wkt: %w", err)
+		}
+		return geom.ToGeoJSON(0), nil
+	case text[0] == 0 || text[0] == 1:
+		geom, err := geos.NewGeomFromWKB(data)
+		if err != nil {
+			return "", fmt.Errorf("can't parse wkb: %w", err)
+		}
+		return geom.ToGeoJSON(0), nil
+	}
+
+	// Hex encoded WKB as it is exported by PostGIS
+	if wkb, err := hex.DecodeString(string(text)); err == nil {
+		geom, err := geos.NewGeomFromWKB(wkb)
+		if err != nil {
+			return "", fmt.Errorf("can't parse hex wkb: %w", err)
+		}
+		return geom.ToGeoJSON(0), nil
+	}
+
+	return "", fmt.Errorf("can't detect area format, expected GeoJSON, KML, WKT or WKB")
+}
+
+func hasWKTPrefix(text []byte) bool {
+	upper := strings.ToUpper(string(text[:min(len(text), 20)]))
+	for _, prefix := range wktPrefixes {
+		if strings.HasPrefix(upper, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseBounds parses "minLon,minLat,maxLon,maxLat" bbox
+func ParseBounds(input string) (*geos.Bounds, error) {
+	parts := strings.Split(input, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("bbox must be minLon,minLat,maxLon,maxLat")
+	}
+
+	values := make([]float64, 0, 4)
+	for _, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, fmt.Errorf("can't parse bbox value %q: %w", part, err)
+		}
+		values = append(values, value)
+	}
+
+	minLon, minLat, maxLon, maxLat := values[0], values[1], values[2], values[3]
+	if minLon >= maxLon || minLat >= maxLat {
+		return nil, fmt.Errorf("bbox must be minLon,minLat,maxLon,maxLat")
+	}
+	if minLon < -180 || maxLon > 180 || minLat < -90 || maxLat > 90 {
+		return nil, fmt.Errorf("bbox is out of lon/lat range")
+	}
+
+	return geos.NewBounds(minLon, minLat, maxLon, maxLat), nil
+}
+
+func parseBbox(input string) (string, error) {
+	bounds, err := ParseBounds(input)
+	if err != nil {
+		return "", err
+	}
+
+	return bounds.Geom().ToGeoJSON(0), nil
+}
+
+type kmlPolygon struct {
+	Outer string   `xml:"outerBoundaryIs>LinearRing>coordinates"`
+	Inner []string `xml:"innerBoundaryIs>LinearRing>coordinates"`
+}
+
+func parseKMLRing(coordinates string) ([][2]float64, error) {
+	ring := make([][2]float64, 0)
+	for _, tuple := range strings.Fields(coordinates) {
+		parts := strings.Split(tuple, ",")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("bad kml coordinate %q", tuple)
+		}
+
+		lng, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad kml longitude %q: %w", parts[0], err)
+		}
+
+		lat, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad kml latitude %q: %w", parts[1], err)
+		}
+
+		ring = append(ring, [2]float64{lng, lat})
+	}
+
+	return ring, nil
+}
+
+// parseKML returns all polygons of KML document as one multipolygon
+func parseKML(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	polygons := make([][][][2]float64, 0)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("can't parse kml: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "Polygon" {
+			continue
+		}
+
+		var polygon kmlPolygon
+		err = decoder.DecodeElement(&polygon, &start)
+		if err != nil {
+			return "", fmt.Errorf("can't parse kml polygon: %w", err)
+		}
+
+		rings := make([][][2]float64, 0, 1+len(polygon.Inner))
+		for _, coordinates := range append([]string{polygon.Outer}, polygon.Inner...) {
+			ring, err := parseKMLRing(coordinates)
+			if err != nil {
+				return "", err
+			}
+			rings = append(rings, ring)
+		}
+
+		polygons = append(polygons, rings)
+	}
+
+	if len(polygons) == 0 {
+		return "", fmt.Errorf("kml has no polygons")
+	}
+
+	geojson, err := json.Marshal(map[string]any{
+		"type":        "MultiPolygon",
+		"coordinates": polygons,
+	})
+	if err != nil {
+		return "", fmt.Errorf("can't marshal kml polygons: %w", err)
+	}
+
+	return string(geojson), nil
+}
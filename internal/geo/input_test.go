@@ -0,0 +1,111 @@
+package geo
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/twpayne/go-geos"
+)
+
+const testWKT = "POLYGON ((49.1 55.77, 49.14 55.77, 49.14 55.8, 49.1 55.8, 49.1 55.77))"
+
+const testKML = `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+  <Document>
+    <Placemark>
+      <name>Area</name>
+      <Polygon>
+        <outerBoundaryIs>
+          <LinearRing>
+            <coordinates>
+              49.1,55.77,0 49.14,55.77,0 49.14,55.8,0 49.1,55.8,0 49.1,55.77,0
+            </coordinates>
+          </LinearRing>
+        </outerBoundaryIs>
+      </Polygon>
+    </Placemark>
+  </Document>
+</kml>`
+
+func writeAreaFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, data, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestReadArea(t *testing.T) {
+	geom, err := geos.NewGeomFromWKT(testWKT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wkb := geom.ToWKB()
+
+	presets := fstest.MapFS{
+		"kazan.geojson": {Data: []byte(geom.ToGeoJSON(0))},
+	}
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"geojson", writeAreaFile(t, "area.geojson", []byte(geom.ToGeoJSON(0)))},
+		{"wkt", writeAreaFile(t, "area.wkt", []byte(testWKT))},
+		{"wkb", writeAreaFile(t, "area.wkb", wkb)},
+		{"hex wkb", writeAreaFile(t, "area.hex", []byte(hex.EncodeToString(wkb)))},
+		{"kml", writeAreaFile(t, "area.kml", []byte(testKML))},
+		{"bbox", "49.10,55.77,49.14,55.80"},
+		{"preset", "kazan"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			geojson, err := ReadArea(test.input, presets)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			area, err := geos.NewGeomFromGeoJSON(geojson)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			bounds := area.Bounds()
+			if bounds.MinX != 49.1 || bounds.MinY != 55.77 || bounds.MaxX != 49.14 || bounds.MaxY != 55.8 {
+				t.Errorf("got bounds %v", bounds)
+			}
+		})
+	}
+}
+
+func TestReadAreaErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"garbage file", writeAreaFile(t, "area.txt", []byte("this is not an area"))},
+		{"empty file", writeAreaFile(t, "empty.txt", []byte(" \n"))},
+		{"kml without polygons", writeAreaFile(t, "area.kml", []byte(`<kml><Document><Placemark><Point><coordinates>49.1,55.77</coordinates></Point></Placemark></Document></kml>`))},
+		{"bad wkt", writeAreaFile(t, "area.wkt", []byte("POLYGON ((49.1 55.77, 49.14"))},
+		{"unknown preset", "samara"},
+		{"swapped bbox", "49.14,55.80,49.10,55.77"},
+		{"bbox out of range", "49.10,55.77,190,55.80"},
+		{"bbox with text", "49.10,55.77,east,55.80"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ReadArea(test.input, fstest.MapFS{}); err == nil {
+				t.Errorf("%s is read as area", test.input)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/paulmach/orb"
+	orbgeo "github.com/paulmach/orb/geo"
+	"github.com/twpayne/go-geos"
+)
+
+// MetersPerLatDegree is a ground length of one latitude degree on orb sphere
+const MetersPerLatDegree = orb.EarthRadius * math.Pi / 180
+
+type poi struct {
+	name string
+	lng  float64
+	lat  float64
+}
+
+// POILayer finds nearest feature of point layer like metro stations or schools
+type POILayer struct {
+	points []poi
+}
+
+// NewPOILayer reads FeatureCollection, feature name is taken from nameProperty,
+// centroids are used for features which are not points
+func NewPOILayer(geojson string, nameProperty string) (*POILayer, error) {
+	var fc FeatureCollection
+	err := json.Unmarshal([]byte(geojson), &fc)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse geojson: %w", err)
+	}
+
+	if fc.Type != "FeatureCollection" {
+		return nil, errors.New("poi geojson must be a FeatureCollection")
+	}
+
+	layer := &POILayer{
+		points: make([]poi, 0, len(fc.Features)),
+	}
+
+	for i, feature := range fc.Features {
+		name, ok := feature.Properties[nameProperty]
+		if !ok {
+			return nil, fmt.Errorf("feature %d has no property '%s'", i, nameProperty)
+		}
+
+		geom, err := geos.NewGeomFromGeoJSON(string(feature.Geometry))
+		if err != nil {
+			return nil, fmt.Errorf("can't parse geometry of feature %d: %w", i, err)
+		}
+
+		if geom.TypeID() != geos.TypeIDPoint {
+			geom = geom.Centroid()
+		}
+
+		layer.points = append(layer.points, poi{
+			name: fmt.Sprint(name),
+			lng:  geom.X(),
+			lat:  geom.Y(),
+		})
+	}
+
+	if len(layer.points) == 0 {
+		return nil, errors.New("poi geojson has no features")
+	}
+
+	sort.Slice(layer.points, func(i, j int) bool {
+		return layer.points[i].lat < layer.points[j].lat
+	})
+
+	return layer, nil
+}
+
+// Nearest returns name of nearest feature and straight-line distance to it in meters,
+// points are sorted by latitude so search stops when latitude difference is bigger than best distance
+func (l *POILayer) Nearest(lng, lat float64) (string, float64) {
+	point := orb.Point{lng, lat}
+	start := sort.Search(len(l.points), func(i int) bool {
+		return l.points[i].lat >= lat
+	})
+
+	bestName := ""
+	bestDistance := math.Inf(1)
+
+	check := func(i int) bool {
+		p := l.points[i]
+		if math.Abs(p.lat-lat)*MetersPerLatDegree > bestDistance {
+			return false
+		}
+
+		distance := orbgeo.DistanceHaversine(point, orb.Point{p.lng, p.lat})
+		if distance < bestDistance {
+			bestName = p.name
+			bestDistance = distance
+		}
+
+		return true
+	}
+
+	for i := start; i < len(l.points); i++ {
+		if !check(i) {
+			break
+		}
+	}
+
+	for i := start - 1; i >= 0; i-- {
+		if !check(i) {
+			break
+		}
+	}
+
+	return bestName, bestDistance
+}
@@ -0,0 +1,66 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/paulmach/orb"
+	orbgeo "github.com/paulmach/orb/geo"
+)
+
+func TestPOILayerNearest(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+
+	features := make([]string, 0)
+	points := make([]orb.Point, 0)
+	for i := 0; i < 200; i++ {
+		point := orb.Point{49.0 + random.Float64()*0.3, 55.7 + random.Float64()*0.2}
+		points = append(points, point)
+		features = append(features, fmt.Sprintf(`{"type":"Feature","properties":{"name":"poi %d"},"geometry":{"type":"Point","coordinates":[%v,%v]}}`, i, point[0], point[1]))
+	}
+
+	layer, err := NewPOILayer(`{"type":"FeatureCollection","features":[`+strings.Join(features, ",")+`]}`, "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queries := []orb.Point{
+		// far points, nearest feature is at the edge of layer
+		{37.6, 55.75},
+		{49.15, 60.0},
+		{49.15, 50.0},
+		{60.0, 55.8},
+	}
+	for i := 0; i < 200; i++ {
+		queries = append(queries, orb.Point{48.9 + random.Float64()*0.5, 55.6 + random.Float64()*0.4})
+	}
+
+	for _, query := range queries {
+		wantName, wantDistance := "", math.Inf(1)
+		for i, point := range points {
+			if distance := orbgeo.DistanceHaversine(query, point); distance < wantDistance {
+				wantName, wantDistance = fmt.Sprintf("poi %d", i), distance
+			}
+		}
+
+		name, distance := layer.Nearest(query[0], query[1])
+		if name != wantName || math.Abs(distance-wantDistance) > 1e-6 {
+			t.Errorf("%v: got %s at %f m, want %s at %f m", query, name, distance, wantName, wantDistance)
+		}
+	}
+}
+
+func TestPOILayerCentroid(t *testing.T) {
+	layer, err := NewPOILayer(`{"type":"FeatureCollection","features":[{"type":"Feature","properties":{"name":"park"},"geometry":{"type":"Polygon","coordinates":[[[49.10,55.78],[49.12,55.78],[49.12,55.80],[49.10,55.80],[49.10,55.78]]]}}]}`, "name")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name, distance := layer.Nearest(49.11, 55.79)
+	if name != "park" || distance > 1 {
+		t.Errorf("got %s at %f m, want park centroid", name, distance)
+	}
+}
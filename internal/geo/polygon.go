@@ -0,0 +1,192 @@
+package geo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/paulmach/orb/project"
+	"github.com/twpayne/go-geos"
+)
+
+// NormalizeReport describes what was changed in input geometry
+type NormalizeReport struct {
+	Geometries int
+	Skipped    []string
+	Repaired   []string
+	Parts      int
+}
+
+// Changed returns true if some geometries were skipped or repaired
+func (r *NormalizeReport) Changed() bool {
+	return len(r.Skipped) > 0 || len(r.Repaired) > 0
+}
+
+func (r *NormalizeReport) String() string {
+	lines := []string{fmt.Sprintf("%d geometries, %d polygon parts after union", r.Geometries, r.Parts)}
+	for _, reason := range r.Skipped {
+		lines = append(lines, "skipped: "+reason)
+	}
+	for _, reason := range r.Repaired {
+		lines = append(lines, "repaired: "+reason)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// collectPolygons adds polygons of geom to list, it walks into collections and multipolygons
+func collectPolygons(geom *geos.Geom, polygons []*geos.Geom, report *NormalizeReport) []*geos.Geom {
+	switch geom.TypeID() {
+	case geos.TypeIDPolygon:
+		report.Geometries++
+
+		if geom.IsEmpty() {
+			report.Skipped = append(report.Skipped, fmt.Sprintf("geometry %d: empty polygon", report.Geometries))
+			return polygons
+		}
+
+		if !geom.IsValid() {
+			report.Repaired = append(report.Repaired, fmt.Sprintf("geometry %d: %s", report.Geometries, geom.IsValidReason()))
+
+			// MakeValid can return collection with lines and points, only polygonal part is needed
+			return collectValidPolygons(geom.MakeValid(), polygons)
+		}
+
+		return append(polygons, geom.Clone())
+	case geos.TypeIDMultiPolygon, geos.TypeIDGeometryCollection:
+		for i := 0; i < geom.NumGeometries(); i++ {
+			polygons = collectPolygons(geom.Geometry(i), polygons, report)
+		}
+
+		return polygons
+	default:
+		report.Geometries++
+		report.Skipped = append(report.Skipped, fmt.Sprintf("geometry %d: %s is not polygonal", report.Geometries, geom.Type()))
+
+		return polygons
+	}
+}
+
+func collectValidPolygons(geom *geos.Geom, polygons []*geos.Geom) []*geos.Geom {
+	switch geom.TypeID() {
+	case geos.TypeIDPolygon:
+		if !geom.IsEmpty() {
+			polygons = append(polygons, geom.Clone())
+		}
+	case geos.TypeIDMultiPolygon, geos.TypeIDGeometryCollection:
+		for i := 0; i < geom.NumGeometries(); i++ {
+			polygons = collectValidPolygons(geom.Geometry(i), polygons)
+		}
+	}
+
+	return polygons
+}
+
+// NormalizeGeoJSON reads geometry, feature or feature collection and returns valid union
+// of all its polygons, holes and multipolygons are kept
+func NormalizeGeoJSON(geojson string) (*geos.Geom, *NormalizeReport, error) {
+	geom, err := geos.NewGeomFromGeoJSON(geojson)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't parse geojson: %w", err)
+	}
+
+	report := &NormalizeReport{}
+	polygons := collectPolygons(geom, nil, report)
+	if len(polygons) == 0 {
+		return nil, report, fmt.Errorf("geojson has no polygons")
+	}
+
+	union := geos.NewCollection(geos.TypeIDMultiPolygon, polygons).UnaryUnion()
+	report.Parts = union.NumGeometries()
+
+	return union, report, nil
+}
+
+// getPolygonParts returns polygons of polygon or multipolygon
+func getPolygonParts(geom *geos.Geom) []*geos.Geom {
+	if geom.TypeID() == geos.TypeIDPolygon {
+		return []*geos.Geom{geom}
+	}
+
+	parts := make([]*geos.Geom, geom.NumGeometries())
+	for i := range parts {
+		parts[i] = geom.Geometry(i)
+	}
+
+	return parts
+}
+
+// groupPolygonParts joins parts which are closer than cellSize to each other,
+// so separated islands are gridded independently, cellSize is in ground meters
+// unless mercatorCellSize is set
+func groupPolygonParts(geom *geos.Geom, cellSize float64, mercatorCellSize bool) []*geos.Geom {
+	parts := getPolygonParts(geom)
+	if len(parts) == 1 {
+		return parts
+	}
+
+	bounds := make([]*geos.Bounds, len(parts))
+	for i, part := range parts {
+		b := reprojectBounds(part.Bounds(), project.WGS84.ToMercator)
+
+		pad := cellSize / 2
+		if !mercatorCellSize {
+			pad *= mercatorScale((b.MinY + b.MaxY) / 2)
+		}
+
+		bounds[i] = geos.NewBounds(b.MinX-pad, b.MinY-pad, b.MaxX+pad, b.MaxY+pad)
+	}
+
+	groupIDs := make([]int, len(parts))
+	for i := range groupIDs {
+		groupIDs[i] = i
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if groupIDs[i] != i {
+			groupIDs[i] = find(groupIDs[i])
+		}
+		return groupIDs[i]
+	}
+
+	// Merge until stable, merged group bounds can touch other groups
+	for merged := true; merged; {
+		merged = false
+
+		groupBounds := make(map[int]*geos.Bounds)
+		for i, b := range bounds {
+			id := find(i)
+			if gb, ok := groupBounds[id]; ok {
+				groupBounds[id] = geos.NewBounds(min(gb.MinX, b.MinX), min(gb.MinY, b.MinY), max(gb.MaxX, b.MaxX), max(gb.MaxY, b.MaxY))
+			} else {
+				groupBounds[id] = b
+			}
+		}
+
+		for id1, b1 := range groupBounds {
+			for id2, b2 := range groupBounds {
+				if id1 < id2 && find(id1) != find(id2) && b1.Intersects(b2) {
+					groupIDs[find(id2)] = find(id1)
+					merged = true
+				}
+			}
+		}
+	}
+
+	groupParts := make(map[int][]*geos.Geom)
+	order := make([]int, 0)
+	for i, part := range parts {
+		id := find(i)
+		if _, ok := groupParts[id]; !ok {
+			order = append(order, id)
+		}
+		groupParts[id] = append(groupParts[id], part.Clone())
+	}
+
+	groups := make([]*geos.Geom, 0, len(order))
+	for _, id := range order {
+		groups = append(groups, geos.NewCollection(geos.TypeIDMultiPolygon, groupParts[id]))
+	}
+
+	return groups
+}
@@ -0,0 +1,124 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/wkb"
+	"github.com/paulmach/orb/project"
+	"github.com/twpayne/go-geos"
+)
+
+const bufferQuadSegments = 8
+
+// PreprocessOptions are applied to area before gridding, zero values turn steps off
+type PreprocessOptions struct {
+	BufferMeters   float64
+	SimplifyMeters float64
+	Exclusions     []string
+}
+
+// PreprocessStep has grid size before and after area change
+type PreprocessStep struct {
+	Name        string
+	CellsBefore int
+	CellsAfter  int
+}
+
+func reprojectGeom(geom *geos.Geom, projection orb.Projection) (*geos.Geom, error) {
+	orbGeom, err := wkb.Unmarshal(geom.ToWKB())
+	if err != nil {
+		return nil, fmt.Errorf("can't convert geometry: %w", err)
+	}
+
+	data, err := wkb.Marshal(project.Geometry(orbGeom, projection))
+	if err != nil {
+		return nil, fmt.Errorf("can't convert geometry: %w", err)
+	}
+
+	return geos.NewGeomFromWKB(data)
+}
+
+// inMercator applies change to geometry in meters, distance is scaled by mercator scale factor
+// at geometry center
+func inMercator(geom *geos.Geom, meters float64, change func(geom *geos.Geom, distance float64) *geos.Geom) (*geos.Geom, error) {
+	bounds := geom.Bounds()
+	scale := 1 / math.Cos((bounds.MinY+bounds.MaxY)/2*math.Pi/180)
+
+	geom3857, err := reprojectGeom(geom, project.WGS84.ToMercator)
+	if err != nil {
+		return nil, err
+	}
+
+	return reprojectGeom(change(geom3857, meters*scale), project.Mercator.ToWGS84)
+}
+
+// PreprocessGeom buffers, simplifies and subtracts exclusion areas from normalized area,
+// steps report how grid changes after them
+func PreprocessGeom(geom *geos.Geom, options PreprocessOptions, grid Grid) (*geos.Geom, []PreprocessStep, error) {
+	steps := make([]PreprocessStep, 0)
+	cells, _, err := grid.getCellBounds(geom)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't get grid cells: %w", err)
+	}
+	cellsCount := len(cells)
+
+	addStep := func(name string, changed *geos.Geom) error {
+		cells, _, err := grid.getCellBounds(changed)
+		if err != nil {
+			return fmt.Errorf("can't get grid cells after %s: %w", name, err)
+		}
+
+		steps = append(steps, PreprocessStep{Name: name, CellsBefore: cellsCount, CellsAfter: len(cells)})
+		geom = changed
+		cellsCount = len(cells)
+		return nil
+	}
+
+	if options.BufferMeters != 0 {
+		buffered, err := inMercator(geom, options.BufferMeters, func(geom *geos.Geom, distance float64) *geos.Geom {
+			return geom.Buffer(distance, bufferQuadSegments)
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't buffer area: %w", err)
+		}
+
+		err = addStep("buffer", buffered)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if options.SimplifyMeters > 0 {
+		simplified, err := inMercator(geom, options.SimplifyMeters, func(geom *geos.Geom, tolerance float64) *geos.Geom {
+			return geom.TopologyPreserveSimplify(tolerance)
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't simplify area: %w", err)
+		}
+
+		err = addStep("simplify", simplified)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, exclusion := range options.Exclusions {
+		exclusionGeom, _, err := NormalizeGeoJSON(exclusion)
+		if err != nil {
+			return nil, nil, fmt.Errorf("can't read exclusion polygon: %w", err)
+		}
+
+		err = addStep("exclusion", geom.Difference(exclusionGeom))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if geom.IsEmpty() {
+		return nil, nil, fmt.Errorf("area is empty after preprocessing")
+	}
+
+	return geom, steps, nil
+}
@@ -0,0 +1,134 @@
+package geo
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/twpayne/go-geos"
+)
+
+func getTestGeom(t *testing.T, geojson string) *geos.Geom {
+	t.Helper()
+
+	geom, _, err := NormalizeGeoJSON(geojson)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return geom
+}
+
+func TestPreprocessBuffer(t *testing.T) {
+	polygon := getTestPolygon(t)
+	grid := Grid{Type: GridTypeRect, CellSize: 500}
+
+	buffered, steps, err := PreprocessGeom(polygon, PreprocessOptions{BufferMeters: 1000}, grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(steps) != 1 || steps[0].Name != "buffer" || steps[0].CellsAfter <= steps[0].CellsBefore {
+		t.Fatalf("got steps %+v, want buffer step which adds cells", steps)
+	}
+
+	before, after := polygon.Bounds(), buffered.Bounds()
+	latDiff := 1000 / MetersPerLatDegree
+	lngDiff := latDiff / math.Cos((before.MinY+before.MaxY)/2*math.Pi/180)
+	for _, diff := range []struct {
+		got, want float64
+	}{
+		{before.MinY - after.MinY, latDiff},
+		{after.MaxY - before.MaxY, latDiff},
+		{before.MinX - after.MinX, lngDiff},
+		{after.MaxX - before.MaxX, lngDiff},
+	} {
+		if math.Abs(diff.got-diff.want) > diff.want*0.05 {
+			t.Errorf("area is buffered by %f degrees, want %f", diff.got, diff.want)
+		}
+	}
+}
+
+func TestPreprocessSimplify(t *testing.T) {
+	// square with 1 m bump on south side
+	polygon := getTestGeom(t, `{"type":"Polygon","coordinates":[[[49.10,55.77],[49.12,55.77],[49.12001,55.77001],[49.12002,55.77],[49.14,55.77],[49.14,55.80],[49.10,55.80],[49.10,55.77]]]}`)
+	grid := Grid{Type: GridTypeRect, CellSize: 500}
+
+	simplified, steps, err := PreprocessGeom(polygon, PreprocessOptions{SimplifyMeters: 50}, grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(steps) != 1 || steps[0].Name != "simplify" || steps[0].CellsAfter != steps[0].CellsBefore {
+		t.Fatalf("got steps %+v, want simplify step which keeps cells", steps)
+	}
+
+	if got := strings.Count(simplified.ToWKT(), ","); got >= strings.Count(polygon.ToWKT(), ",") {
+		t.Errorf("simplified area has %d vertices, bump is not removed", got+1)
+	}
+}
+
+func TestPreprocessExclusion(t *testing.T) {
+	polygon := getTestPolygon(t)
+	grid := Grid{Type: GridTypeRect, CellSize: 250}
+	exclusion := `{"type":"Polygon","coordinates":[[[49.1151,55.7801],[49.1249,55.7801],[49.1249,55.7899],[49.1151,55.7899],[49.1151,55.7801]]]}`
+
+	cells, err := GetGridCells(polygon, grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exclusionGeom := getTestGeom(t, exclusion)
+	removed := 0
+	for _, cell := range cells {
+		if exclusionGeom.Contains(cell.Geometry) {
+			removed++
+		}
+	}
+	if removed == 0 {
+		t.Fatal("exclusion doesn't cover any cell")
+	}
+
+	_, steps, err := PreprocessGeom(polygon, PreprocessOptions{Exclusions: []string{exclusion}}, grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(steps) != 1 || steps[0].Name != "exclusion" {
+		t.Fatalf("got steps %+v, want exclusion step", steps)
+	}
+
+	if steps[0].CellsBefore != len(cells) || steps[0].CellsBefore-steps[0].CellsAfter != removed {
+		t.Errorf("got %d cells before and %d after, want %d removed of %d", steps[0].CellsBefore, steps[0].CellsAfter, removed, len(cells))
+	}
+}
+
+func TestPreprocessSteps(t *testing.T) {
+	polygon := getTestPolygon(t)
+	grid := Grid{Type: GridTypeRect, CellSize: 500}
+	exclusion := `{"type":"Polygon","coordinates":[[[49.115,55.78],[49.125,55.78],[49.125,55.79],[49.115,55.79],[49.115,55.78]]]}`
+
+	_, steps, err := PreprocessGeom(polygon, PreprocessOptions{}, grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 0 {
+		t.Errorf("got steps %+v without options", steps)
+	}
+
+	_, steps, err = PreprocessGeom(polygon, PreprocessOptions{BufferMeters: 500, SimplifyMeters: 10, Exclusions: []string{exclusion}}, grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, 0, len(steps))
+	for i, step := range steps {
+		names = append(names, step.Name)
+		if i > 0 && step.CellsBefore != steps[i-1].CellsAfter {
+			t.Errorf("%s starts with %d cells, previous step ended with %d", step.Name, step.CellsBefore, steps[i-1].CellsAfter)
+		}
+	}
+	if strings.Join(names, ",") != "buffer,simplify,exclusion" {
+		t.Errorf("got steps %v", names)
+	}
+}
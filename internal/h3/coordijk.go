@@ -0,0 +1,318 @@
+// Copyright 2016-2021 Uber Technologies, Inc.
+// Copyright 2026 cianparser-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Ported to Go from src/h3lib/lib/coordijk.c of github.com/uber/h3 v4.1.0.
+
+package h3
+
+import "math"
+
+// coordIJK is a hex grid coordinate with three 120 degree axes, normalized
+// coordinates have no negative components and at least one zero component
+type coordIJK struct {
+	i, j, k int
+}
+
+// vec2d is a point on face plane in hex2d coordinates
+type vec2d struct {
+	x, y float64
+}
+
+// digit is a child position inside of parent cell
+type digit int
+
+const (
+	centerDigit digit = iota
+	kAxesDigit
+	jAxesDigit
+	jkAxesDigit
+	iAxesDigit
+	ikAxesDigit
+	ijAxesDigit
+	invalidDigit
+)
+
+// unitVecs are directions of digits
+var unitVecs = [...]coordIJK{
+	{0, 0, 0},
+	{0, 0, 1},
+	{0, 1, 0},
+	{0, 1, 1},
+	{1, 0, 0},
+	{1, 0, 1},
+	{1, 1, 0},
+}
+
+const (
+	sqrt3Over2 = 0.8660254037844386467637231707529361834714
+	rSin60     = 1.1547005383792515290182975610039149112953
+)
+
+func (c coordIJK) add(o coordIJK) coordIJK {
+	return coordIJK{c.i + o.i, c.j + o.j, c.k + o.k}
+}
+
+func (c coordIJK) sub(o coordIJK) coordIJK {
+	return coordIJK{c.i - o.i, c.j - o.j, c.k - o.k}
+}
+
+func (c coordIJK) scale(factor int) coordIJK {
+	return coordIJK{c.i * factor, c.j * factor, c.k * factor}
+}
+
+func (c coordIJK) normalize() coordIJK {
+	if c.i < 0 {
+		c.j -= c.i
+		c.k -= c.i
+		c.i = 0
+	}
+	if c.j < 0 {
+		c.i -= c.j
+		c.k -= c.j
+		c.j = 0
+	}
+	if c.k < 0 {
+		c.i -= c.k
+		c.j -= c.k
+		c.k = 0
+	}
+
+	minimum := min(c.i, c.j, c.k)
+	if minimum > 0 {
+		c.i -= minimum
+		c.j -= minimum
+		c.k -= minimum
+	}
+
+	return c
+}
+
+// combine returns sum of unit vectors scaled by coordinate components
+func (c coordIJK) combine(iVec, jVec, kVec coordIJK) coordIJK {
+	return iVec.scale(c.i).add(jVec.scale(c.j)).add(kVec.scale(c.k)).normalize()
+}
+
+func (c coordIJK) toHex2d() vec2d {
+	i := c.i - c.k
+	j := c.j - c.k
+
+	return vec2d{x: float64(i) - 0.5*float64(j), y: float64(j) * sqrt3Over2}
+}
+
+// toDigit returns digit of unit vector or invalid digit
+func (c coordIJK) toDigit() digit {
+	c = c.normalize()
+	for d, vec := range unitVecs {
+		if c == vec {
+			return digit(d)
+		}
+	}
+
+	return invalidDigit
+}
+
+// upAp7 returns parent coordinate in counter-clockwise aperture 7 grid
+func (c coordIJK) upAp7() coordIJK {
+	i := c.i - c.k
+	j := c.j - c.k
+
+	return coordIJK{
+		i: int(math.Round(float64(3*i-j) / 7)),
+		j: int(math.Round(float64(i+2*j) / 7)),
+	}.normalize()
+}
+
+// upAp7r returns parent coordinate in clockwise aperture 7 grid
+func (c coordIJK) upAp7r() coordIJK {
+	i := c.i - c.k
+	j := c.j - c.k
+
+	return coordIJK{
+		i: int(math.Round(float64(2*i+j) / 7)),
+		j: int(math.Round(float64(3*j-i) / 7)),
+	}.normalize()
+}
+
+// downAp7 returns center child coordinate in counter-clockwise aperture 7 grid
+func (c coordIJK) downAp7() coordIJK {
+	return c.combine(coordIJK{3, 0, 1}, coordIJK{1, 3, 0}, coordIJK{0, 1, 3})
+}
+
+// downAp7r returns center child coordinate in clockwise aperture 7 grid
+func (c coordIJK) downAp7r() coordIJK {
+	return c.combine(coordIJK{3, 1, 0}, coordIJK{0, 3, 1}, coordIJK{1, 0, 3})
+}
+
+// downAp3 returns center child coordinate in counter-clockwise aperture 3 grid
+func (c coordIJK) downAp3() coordIJK {
+	return c.combine(coordIJK{2, 0, 1}, coordIJK{1, 2, 0}, coordIJK{0, 1, 2})
+}
+
+// downAp3r returns center child coordinate in clockwise aperture 3 grid
+func (c coordIJK) downAp3r() coordIJK {
+	return c.combine(coordIJK{2, 1, 0}, coordIJK{0, 2, 1}, coordIJK{1, 0, 2})
+}
+
+func (c coordIJK) neighbor(d digit) coordIJK {
+	if d <= centerDigit || d >= invalidDigit {
+		return c
+	}
+
+	return c.add(unitVecs[d]).normalize()
+}
+
+func (c coordIJK) rotate60ccw() coordIJK {
+	return c.combine(coordIJK{1, 1, 0}, coordIJK{0, 1, 1}, coordIJK{1, 0, 1})
+}
+
+func (c coordIJK) rotate60cw() coordIJK {
+	return c.combine(coordIJK{1, 0, 1}, coordIJK{1, 1, 0}, coordIJK{0, 1, 1})
+}
+
+func (d digit) rotate60ccw() digit {
+	switch d {
+	case kAxesDigit:
+		return ikAxesDigit
+	case ikAxesDigit:
+		return iAxesDigit
+	case iAxesDigit:
+		return ijAxesDigit
+	case ijAxesDigit:
+		return jAxesDigit
+	case jAxesDigit:
+		return jkAxesDigit
+	case jkAxesDigit:
+		return kAxesDigit
+	default:
+		return d
+	}
+}
+
+func (d digit) rotate60cw() digit {
+	switch d {
+	case kAxesDigit:
+		return jkAxesDigit
+	case jkAxesDigit:
+		return jAxesDigit
+	case jAxesDigit:
+		return ijAxesDigit
+	case ijAxesDigit:
+		return iAxesDigit
+	case iAxesDigit:
+		return ikAxesDigit
+	case ikAxesDigit:
+		return kAxesDigit
+	default:
+		return d
+	}
+}
+
+// hex2dToCoordIJK returns coordinate of hex containing point
+func hex2dToCoordIJK(v vec2d) coordIJK {
+	var c coordIJK
+
+	a1 := math.Abs(v.x)
+	a2 := math.Abs(v.y)
+
+	// reverse conversion
+	x2 := a2 * rSin60
+	x1 := a1 + x2/2
+
+	m1 := int(x1)
+	m2 := int(x2)
+
+	r1 := x1 - float64(m1)
+	r2 := x2 - float64(m2)
+
+	if r1 < 0.5 {
+		if r1 < 1.0/3 {
+			c.i = m1
+			if r2 < (1+r1)/2 {
+				c.j = m2
+			} else {
+				c.j = m2 + 1
+			}
+		} else {
+			if r2 < 1-r1 {
+				c.j = m2
+			} else {
+				c.j = m2 + 1
+			}
+
+			if 1-r1 <= r2 && r2 < 2*r1 {
+				c.i = m1 + 1
+			} else {
+				c.i = m1
+			}
+		}
+	} else {
+		if r1 < 2.0/3 {
+			if r2 < 1-r1 {
+				c.j = m2
+			} else {
+				c.j = m2 + 1
+			}
+
+			if 2*r1-1 < r2 && r2 < 1-r1 {
+				c.i = m1
+			} else {
+				c.i = m1 + 1
+			}
+		} else {
+			c.i = m1 + 1
+			if r2 < r1/2 {
+				c.j = m2
+			} else {
+				c.j = m2 + 1
+			}
+		}
+	}
+
+	// fold across the axes if necessary
+	if v.x < 0 {
+		if c.j%2 == 0 {
+			axisi := c.j / 2
+			diff := c.i - axisi
+			c.i -= 2 * diff
+		} else {
+			axisi := (c.j + 1) / 2
+			diff := c.i - axisi
+			c.i -= 2*diff + 1
+		}
+	}
+
+	if v.y < 0 {
+		c.i -= (2*c.j + 1) / 2
+		c.j = -c.j
+	}
+
+	return c.normalize()
+}
+
+// intersect returns intersection point of lines p0-p1 and p2-p3
+func intersect(p0, p1, p2, p3 vec2d) vec2d {
+	s1 := vec2d{p1.x - p0.x, p1.y - p0.y}
+	s2 := vec2d{p3.x - p2.x, p3.y - p2.y}
+
+	t := (s2.x*(p0.y-p2.y) - s2.y*(p0.x-p2.x)) / (-s2.x*s1.y + s1.x*s2.y)
+
+	return vec2d{p0.x + t*s1.x, p0.y + t*s1.y}
+}
+
+func (v vec2d) almostEquals(o vec2d) bool {
+	const epsilon = 1.1920929e-7
+
+	return math.Abs(v.x-o.x) < epsilon && math.Abs(v.y-o.y) < epsilon
+}
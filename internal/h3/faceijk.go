@@ -0,0 +1,426 @@
+// Copyright 2016-2021 Uber Technologies, Inc.
+// Copyright 2026 cianparser-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Ported to Go from src/h3lib/lib/faceijk.c of github.com/uber/h3 v4.1.0.
+
+package h3
+
+import "math"
+
+const numFaces = 20
+
+const (
+	epsilon = 0.0000000000000001
+	// resolution 0 unit length in gnomonic projection
+	res0UGnomonic = 0.38196601125010500003
+	sqrt7         = 2.6457513110645905905016157536392604257102
+	// rotation angle between Class II and Class III resolution axes, asin(sqrt(3/28))
+	ap7RotRads = 0.333473172251832115336090755351601070065900389
+)
+
+// faceIJK is a coordinate on one of icosahedron faces
+type faceIJK struct {
+	face  int
+	coord coordIJK
+}
+
+// latLng is a point in radians
+type latLng struct {
+	lat, lng float64
+}
+
+type overage int
+
+const (
+	noOverage overage = iota
+	// on face edge, only occurs on substrate grids
+	faceEdge
+	// overage on new face interior
+	newFace
+)
+
+// quadrants of face neighbors
+const (
+	ij = 1
+	ki = 2
+	jk = 3
+)
+
+// faceOrientIJK is a translation and rotation of coordinates to neighbor face
+type faceOrientIJK struct {
+	face      int
+	translate coordIJK
+	ccwRot60  int
+}
+
+// faceCenterGeo are icosahedron face centers
+var faceCenterGeo = [numFaces]latLng{
+	{0.803582649718989942, 1.248397419617396099},
+	{1.307747883455638156, 2.536945009877921159},
+	{1.054751253523952054, -1.347517358900396623},
+	{0.600191595538186799, -0.450603909469755746},
+	{0.491715428198773866, 0.401988202911306943},
+	{0.172745327415618701, 1.678146885280433686},
+	{0.605929321571350690, 2.953923329812411617},
+	{0.427370518328979641, -1.888876200336285401},
+	{-0.079066118549212831, -0.733429513380867741},
+	{-0.230961644455383637, 0.506495587332349035},
+	{0.079066118549212831, 2.408163140208925497},
+	{0.230961644455383637, -2.635097066257444203},
+	{-0.172745327415618701, -1.463445768309359553},
+	{-0.605929321571350690, -0.187669323777381622},
+	{-0.427370518328979641, 1.252716453253507838},
+	{-0.600191595538186799, 2.690988744120037492},
+	{-0.491715428198773866, -2.739604450678486295},
+	{-0.803582649718989942, -1.893195233972397139},
+	{-1.307747883455638156, -0.604647643711872080},
+	{-1.054751253523952054, 1.794075294689396615},
+}
+
+// faceAxisAzRads are azimuths of Class II i-axis from face centers
+var faceAxisAzRads = [numFaces]float64{
+	5.619958268523939882,
+	5.760339081714187279,
+	0.780213654393430055,
+	0.430469363979999913,
+	6.130269123335111400,
+	2.692877706530642877,
+	2.982963003477243874,
+	3.532912002790141181,
+	3.494305004259568154,
+	3.003214169499538391,
+	5.930472956509811562,
+	0.138378484090254847,
+	0.448714947059150361,
+	0.158629650112549365,
+	5.891865957979238535,
+	2.711123289609793325,
+	3.294508837434268316,
+	3.804819692245439833,
+	3.664438879055192436,
+	2.361378999196363184,
+}
+
+// faceNeighbors are orientations of central face and its ij, ki and jk neighbors
+var faceNeighbors = [numFaces][4]faceOrientIJK{
+	{{0, coordIJK{0, 0, 0}, 0}, {4, coordIJK{2, 0, 2}, 1}, {1, coordIJK{2, 2, 0}, 5}, {5, coordIJK{0, 2, 2}, 3}},
+	{{1, coordIJK{0, 0, 0}, 0}, {0, coordIJK{2, 0, 2}, 1}, {2, coordIJK{2, 2, 0}, 5}, {6, coordIJK{0, 2, 2}, 3}},
+	{{2, coordIJK{0, 0, 0}, 0}, {1, coordIJK{2, 0, 2}, 1}, {3, coordIJK{2, 2, 0}, 5}, {7, coordIJK{0, 2, 2}, 3}},
+	{{3, coordIJK{0, 0, 0}, 0}, {2, coordIJK{2, 0, 2}, 1}, {4, coordIJK{2, 2, 0}, 5}, {8, coordIJK{0, 2, 2}, 3}},
+	{{4, coordIJK{0, 0, 0}, 0}, {3, coordIJK{2, 0, 2}, 1}, {0, coordIJK{2, 2, 0}, 5}, {9, coordIJK{0, 2, 2}, 3}},
+	{{5, coordIJK{0, 0, 0}, 0}, {10, coordIJK{2, 2, 0}, 3}, {14, coordIJK{2, 0, 2}, 3}, {0, coordIJK{0, 2, 2}, 3}},
+	{{6, coordIJK{0, 0, 0}, 0}, {11, coordIJK{2, 2, 0}, 3}, {10, coordIJK{2, 0, 2}, 3}, {1, coordIJK{0, 2, 2}, 3}},
+	{{7, coordIJK{0, 0, 0}, 0}, {12, coordIJK{2, 2, 0}, 3}, {11, coordIJK{2, 0, 2}, 3}, {2, coordIJK{0, 2, 2}, 3}},
+	{{8, coordIJK{0, 0, 0}, 0}, {13, coordIJK{2, 2, 0}, 3}, {12, coordIJK{2, 0, 2}, 3}, {3, coordIJK{0, 2, 2}, 3}},
+	{{9, coordIJK{0, 0, 0}, 0}, {14, coordIJK{2, 2, 0}, 3}, {13, coordIJK{2, 0, 2}, 3}, {4, coordIJK{0, 2, 2}, 3}},
+	{{10, coordIJK{0, 0, 0}, 0}, {5, coordIJK{2, 2, 0}, 3}, {6, coordIJK{2, 0, 2}, 3}, {15, coordIJK{0, 2, 2}, 3}},
+	{{11, coordIJK{0, 0, 0}, 0}, {6, coordIJK{2, 2, 0}, 3}, {7, coordIJK{2, 0, 2}, 3}, {16, coordIJK{0, 2, 2}, 3}},
+	{{12, coordIJK{0, 0, 0}, 0}, {7, coordIJK{2, 2, 0}, 3}, {8, coordIJK{2, 0, 2}, 3}, {17, coordIJK{0, 2, 2}, 3}},
+	{{13, coordIJK{0, 0, 0}, 0}, {8, coordIJK{2, 2, 0}, 3}, {9, coordIJK{2, 0, 2}, 3}, {18, coordIJK{0, 2, 2}, 3}},
+	{{14, coordIJK{0, 0, 0}, 0}, {9, coordIJK{2, 2, 0}, 3}, {5, coordIJK{2, 0, 2}, 3}, {19, coordIJK{0, 2, 2}, 3}},
+	{{15, coordIJK{0, 0, 0}, 0}, {16, coordIJK{2, 0, 2}, 1}, {19, coordIJK{2, 2, 0}, 5}, {10, coordIJK{0, 2, 2}, 3}},
+	{{16, coordIJK{0, 0, 0}, 0}, {17, coordIJK{2, 0, 2}, 1}, {15, coordIJK{2, 2, 0}, 5}, {11, coordIJK{0, 2, 2}, 3}},
+	{{17, coordIJK{0, 0, 0}, 0}, {18, coordIJK{2, 0, 2}, 1}, {16, coordIJK{2, 2, 0}, 5}, {12, coordIJK{0, 2, 2}, 3}},
+	{{18, coordIJK{0, 0, 0}, 0}, {19, coordIJK{2, 0, 2}, 1}, {17, coordIJK{2, 2, 0}, 5}, {13, coordIJK{0, 2, 2}, 3}},
+	{{19, coordIJK{0, 0, 0}, 0}, {15, coordIJK{2, 0, 2}, 1}, {18, coordIJK{2, 2, 0}, 5}, {14, coordIJK{0, 2, 2}, 3}},
+}
+
+// adjacentFaceDir are quadrants of neighbor faces, 0 for the same face and -1 for not adjacent faces
+var adjacentFaceDir = [numFaces][numFaces]int{
+	{0, 2, -1, -1, 1, 3, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1},
+	{1, 0, 2, -1, -1, -1, 3, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1},
+	{-1, 1, 0, 2, -1, -1, -1, 3, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1},
+	{-1, -1, 1, 0, 2, -1, -1, -1, 3, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1},
+	{2, -1, -1, 1, 0, -1, -1, -1, -1, 3, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1},
+	{3, -1, -1, -1, -1, 0, -1, -1, -1, -1, 1, -1, -1, -1, 2, -1, -1, -1, -1, -1},
+	{-1, 3, -1, -1, -1, -1, 0, -1, -1, -1, 2, 1, -1, -1, -1, -1, -1, -1, -1, -1},
+	{-1, -1, 3, -1, -1, -1, -1, 0, -1, -1, -1, 2, 1, -1, -1, -1, -1, -1, -1, -1},
+	{-1, -1, -1, 3, -1, -1, -1, -1, 0, -1, -1, -1, 2, 1, -1, -1, -1, -1, -1, -1},
+	{-1, -1, -1, -1, 3, -1, -1, -1, -1, 0, -1, -1, -1, 2, 1, -1, -1, -1, -1, -1},
+	{-1, -1, -1, -1, -1, 1, 2, -1, -1, -1, 0, -1, -1, -1, -1, 3, -1, -1, -1, -1},
+	{-1, -1, -1, -1, -1, -1, 1, 2, -1, -1, -1, 0, -1, -1, -1, -1, 3, -1, -1, -1},
+	{-1, -1, -1, -1, -1, -1, -1, 1, 2, -1, -1, -1, 0, -1, -1, -1, -1, 3, -1, -1},
+	{-1, -1, -1, -1, -1, -1, -1, -1, 1, 2, -1, -1, -1, 0, -1, -1, -1, -1, 3, -1},
+	{-1, -1, -1, -1, -1, 2, -1, -1, -1, 1, -1, -1, -1, -1, 0, -1, -1, -1, -1, 3},
+	{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1, 3, -1, -1, -1, -1, 0, 1, -1, -1, 2},
+	{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, 3, -1, -1, -1, 2, 0, 1, -1, -1},
+	{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, 3, -1, -1, -1, 2, 0, 1, -1},
+	{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, 3, -1, -1, -1, 2, 0, 1},
+	{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, -1, 3, 1, -1, -1, 2, 0},
+}
+
+// faceCenterPoint are face centers on unit sphere
+var faceCenterPoint = getFaceCenterPoints()
+
+func getFaceCenterPoints() [numFaces][3]float64 {
+	var points [numFaces][3]float64
+	for face, center := range faceCenterGeo {
+		points[face] = center.toVec3d()
+	}
+
+	return points
+}
+
+func isResolutionClassIII(res int) bool {
+	return res%2 == 1
+}
+
+// maxDimByCIIRes returns maximum face coordinate at Class II resolution
+func maxDimByCIIRes(res int) int {
+	return 2 * unitScaleByCIIRes(res)
+}
+
+// unitScaleByCIIRes returns number of resolution units in resolution 0 unit
+func unitScaleByCIIRes(res int) int {
+	scale := 1
+	for r := 0; r < res; r += 2 {
+		scale *= 7
+	}
+
+	return scale
+}
+
+func posAngleRads(rads float64) float64 {
+	if rads < 0 {
+		rads += 2 * math.Pi
+	}
+	if rads >= 2*math.Pi {
+		rads -= 2 * math.Pi
+	}
+
+	return rads
+}
+
+func constrainLng(lng float64) float64 {
+	for lng > math.Pi {
+		lng -= 2 * math.Pi
+	}
+	for lng < -math.Pi {
+		lng += 2 * math.Pi
+	}
+
+	return lng
+}
+
+func (g latLng) toVec3d() [3]float64 {
+	r := math.Cos(g.lat)
+
+	return [3]float64{math.Cos(g.lng) * r, math.Sin(g.lng) * r, math.Sin(g.lat)}
+}
+
+// azimuthTo returns azimuth from g to p
+func (g latLng) azimuthTo(p latLng) float64 {
+	return math.Atan2(
+		math.Cos(p.lat)*math.Sin(p.lng-g.lng),
+		math.Cos(g.lat)*math.Sin(p.lat)-math.Sin(g.lat)*math.Cos(p.lat)*math.Cos(p.lng-g.lng),
+	)
+}
+
+// atAzimuthDistance returns point at azimuth and spherical distance from g
+func (g latLng) atAzimuthDistance(az, distance float64) latLng {
+	if distance < epsilon {
+		return g
+	}
+
+	var p latLng
+
+	az = posAngleRads(az)
+
+	// due north or south azimuth
+	if az < epsilon || math.Abs(az-math.Pi) < epsilon {
+		if az < epsilon {
+			p.lat = g.lat + distance
+		} else {
+			p.lat = g.lat - distance
+		}
+
+		if math.Abs(p.lat-math.Pi/2) < epsilon {
+			return latLng{math.Pi / 2, 0}
+		}
+		if math.Abs(p.lat+math.Pi/2) < epsilon {
+			return latLng{-math.Pi / 2, 0}
+		}
+
+		p.lng = constrainLng(g.lng)
+		return p
+	}
+
+	sinLat := math.Sin(g.lat)*math.Cos(distance) + math.Cos(g.lat)*math.Sin(distance)*math.Cos(az)
+	sinLat = math.Max(-1, math.Min(1, sinLat))
+	p.lat = math.Asin(sinLat)
+
+	if math.Abs(p.lat-math.Pi/2) < epsilon {
+		return latLng{math.Pi / 2, 0}
+	}
+	if math.Abs(p.lat+math.Pi/2) < epsilon {
+		return latLng{-math.Pi / 2, 0}
+	}
+
+	sinLng := math.Sin(az) * math.Sin(distance) / math.Cos(p.lat)
+	cosLng := (math.Cos(distance) - math.Sin(g.lat)*math.Sin(p.lat)) / math.Cos(g.lat) / math.Cos(p.lat)
+	sinLng = math.Max(-1, math.Min(1, sinLng))
+	cosLng = math.Max(-1, math.Min(1, cosLng))
+	p.lng = constrainLng(g.lng + math.Atan2(sinLng, cosLng))
+
+	return p
+}
+
+// closestFace returns face with center closest to g and squared euclidean distance to it
+func closestFace(g latLng) (int, float64) {
+	v := g.toVec3d()
+
+	face := 0
+	minSqd := 5.0
+	for f, center := range faceCenterPoint {
+		dx := center[0] - v[0]
+		dy := center[1] - v[1]
+		dz := center[2] - v[2]
+
+		sqd := dx*dx + dy*dy + dz*dz
+		if sqd < minSqd {
+			face = f
+			minSqd = sqd
+		}
+	}
+
+	return face, minSqd
+}
+
+// geoToHex2d returns face and hex2d coordinates of g at resolution
+func geoToHex2d(g latLng, res int) (int, vec2d) {
+	face, sqd := closestFace(g)
+
+	// cos(r) = 1 - 2 * sin^2(r/2) = 1 - 2 * (sqd / 4) = 1 - sqd/2
+	r := math.Acos(1 - sqd/2)
+	if r < epsilon {
+		return face, vec2d{}
+	}
+
+	// ccw theta from Class II i-axis
+	theta := posAngleRads(faceAxisAzRads[face] - posAngleRads(faceCenterGeo[face].azimuthTo(g)))
+	if isResolutionClassIII(res) {
+		theta = posAngleRads(theta - ap7RotRads)
+	}
+
+	// gnomonic scaling
+	r = math.Tan(r) / res0UGnomonic
+	for i := 0; i < res; i++ {
+		r *= sqrt7
+	}
+
+	return face, vec2d{r * math.Cos(theta), r * math.Sin(theta)}
+}
+
+// hex2dToGeo returns point of hex2d coordinates on face, substrate grids are
+// scaled by aperture 3 and are already rotated for Class III
+func hex2dToGeo(v vec2d, face int, res int, substrate bool) latLng {
+	r := math.Hypot(v.x, v.y)
+	if r < epsilon {
+		return faceCenterGeo[face]
+	}
+
+	theta := math.Atan2(v.y, v.x)
+
+	for i := 0; i < res; i++ {
+		r /= sqrt7
+	}
+
+	if substrate {
+		r /= 3
+		if isResolutionClassIII(res) {
+			r /= sqrt7
+		}
+	}
+
+	// inverse gnomonic scaling
+	r = math.Atan(r * res0UGnomonic)
+
+	if !substrate && isResolutionClassIII(res) {
+		theta = posAngleRads(theta + ap7RotRads)
+	}
+
+	// theta as azimuth
+	theta = posAngleRads(faceAxisAzRads[face] - theta)
+
+	return faceCenterGeo[face].atAzimuthDistance(theta, r)
+}
+
+func geoToFaceIJK(g latLng, res int) faceIJK {
+	face, v := geoToHex2d(g, res)
+
+	return faceIJK{face: face, coord: hex2dToCoordIJK(v)}
+}
+
+func (f faceIJK) toGeo(res int) latLng {
+	return hex2dToGeo(f.coord.toHex2d(), f.face, res, false)
+}
+
+// adjustOverageClassII moves coordinate to neighbor face if it is outside of its face,
+// pentLeading4 adjusts for the pentagon missing sequence
+func (f *faceIJK) adjustOverageClassII(res int, pentLeading4 bool, substrate bool) overage {
+	maxDim := maxDimByCIIRes(res)
+	if substrate {
+		maxDim *= 3
+	}
+
+	sum := f.coord.i + f.coord.j + f.coord.k
+	if substrate && sum == maxDim {
+		return faceEdge
+	}
+	if sum <= maxDim {
+		return noOverage
+	}
+
+	var orient faceOrientIJK
+	if f.coord.k > 0 {
+		if f.coord.j > 0 {
+			orient = faceNeighbors[f.face][jk]
+		} else {
+			orient = faceNeighbors[f.face][ki]
+
+			if pentLeading4 {
+				// rotate around pentagon center to adjust for the missing sequence
+				origin := coordIJK{maxDim, 0, 0}
+				f.coord = f.coord.sub(origin).rotate60cw().add(origin)
+			}
+		}
+	} else {
+		orient = faceNeighbors[f.face][ij]
+	}
+
+	f.face = orient.face
+	for i := 0; i < orient.ccwRot60; i++ {
+		f.coord = f.coord.rotate60ccw()
+	}
+
+	unitScale := unitScaleByCIIRes(res)
+	if substrate {
+		unitScale *= 3
+	}
+	f.coord = f.coord.add(orient.translate.scale(unitScale)).normalize()
+
+	// overage points on pentagon boundaries can end up on edges
+	if substrate && f.coord.i+f.coord.j+f.coord.k == maxDim {
+		return faceEdge
+	}
+
+	return newFace
+}
+
+// adjustPentVertOverage moves pentagon vertex through faces until it is on face
+func (f *faceIJK) adjustPentVertOverage(res int) {
+	for f.adjustOverageClassII(res, false, true) == newFace {
+	}
+}
@@ -0,0 +1,479 @@
+// Copyright 2016-2021 Uber Technologies, Inc.
+// Copyright 2026 cianparser-go authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Ported to Go from src/h3lib/lib/h3Index.c and latLng.c of github.com/uber/h3 v4.1.0.
+
+// Package h3 is a pure Go port of cell indexing from Uber H3 library, it converts points
+// to cells, cells to their centers and boundaries with the same ids as H3 does
+package h3
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/paulmach/orb"
+)
+
+// MaxResolution is the finest H3 resolution
+const MaxResolution = 15
+
+const (
+	cellMode = 1
+
+	modeOffset      = 59
+	resOffset       = 52
+	baseCellOffset  = 45
+	perDigitOffset  = 3
+	digitMask       = 7
+	resMask         = 15
+	baseCellMask    = 127
+	initIndex       = 35184372088831 // all digits are 7
+	numHexVerts     = 6
+	numPentVerts    = 5
+	maxFaceCoord    = 2
+	invalidBaseCell = -1
+)
+
+// Cell is H3 cell index
+type Cell uint64
+
+// LatLngToCell returns cell at resolution which contains point in degrees
+func LatLngToCell(lat, lng float64, res int) (Cell, error) {
+	if res < 0 || res > MaxResolution {
+		return 0, fmt.Errorf("resolution must be from 0 to %d", MaxResolution)
+	}
+
+	if math.IsNaN(lat) || math.IsNaN(lng) || math.IsInf(lat, 0) || math.IsInf(lng, 0) {
+		return 0, fmt.Errorf("invalid point: %f, %f", lat, lng)
+	}
+
+	g := latLng{lat * math.Pi / 180, lng * math.Pi / 180}
+
+	return faceIJKToCell(geoToFaceIJK(g, res), res), nil
+}
+
+// ParseCell parses cell from hex string
+func ParseCell(s string) (Cell, error) {
+	value, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("can't parse cell: %w", err)
+	}
+
+	cell := Cell(value)
+	if !cell.IsValid() {
+		return 0, fmt.Errorf("invalid cell: %s", s)
+	}
+
+	return cell, nil
+}
+
+func (c Cell) String() string {
+	return strconv.FormatUint(uint64(c), 16)
+}
+
+// Resolution returns cell resolution
+func (c Cell) Resolution() int {
+	return int(uint64(c)>>resOffset) & resMask
+}
+
+// BaseCell returns number of resolution 0 cell containing cell
+func (c Cell) BaseCell() int {
+	return int(uint64(c)>>baseCellOffset) & baseCellMask
+}
+
+// IsPentagon returns true for cells with 5 vertices
+func (c Cell) IsPentagon() bool {
+	return baseCells[c.BaseCell()].isPentagon && c.leadingNonZeroDigit() == centerDigit
+}
+
+// IsValid checks cell mode, base cell and digits
+func (c Cell) IsValid() bool {
+	if uint64(c)>>63 != 0 || int(uint64(c)>>modeOffset)&15 != cellMode {
+		return false
+	}
+
+	if c.BaseCell() >= numBaseCells {
+		return false
+	}
+
+	res := c.Resolution()
+	for r := 1; r <= MaxResolution; r++ {
+		d := c.digit(r)
+		if r <= res && d == invalidDigit || r > res && d != invalidDigit {
+			return false
+		}
+	}
+
+	if baseCells[c.BaseCell()].isPentagon && c.leadingNonZeroDigit() == kAxesDigit {
+		return false
+	}
+
+	return true
+}
+
+// LatLng returns cell center in degrees
+func (c Cell) LatLng() (float64, float64) {
+	g := c.toFaceIJK().toGeo(c.Resolution())
+
+	return g.lat * 180 / math.Pi, g.lng * 180 / math.Pi
+}
+
+// Boundary returns closed cell boundary in lng, lat degrees, vertices are counter-clockwise
+func (c Cell) Boundary() orb.Ring {
+	f := c.toFaceIJK()
+
+	var verts []latLng
+	if c.IsPentagon() {
+		verts = f.pentToBoundary(c.Resolution())
+	} else {
+		verts = f.toBoundary(c.Resolution())
+	}
+
+	ring := make(orb.Ring, 0, len(verts)+1)
+	for _, v := range verts {
+		ring = append(ring, orb.Point{v.lng * 180 / math.Pi, v.lat * 180 / math.Pi})
+	}
+
+	return append(ring, ring[0])
+}
+
+func (c Cell) digit(r int) digit {
+	return digit(uint64(c)>>((MaxResolution-r)*perDigitOffset)) & digitMask
+}
+
+func (c Cell) setDigit(r int, d digit) Cell {
+	offset := (MaxResolution - r) * perDigitOffset
+
+	return Cell(uint64(c)&^(digitMask<<offset) | uint64(d)<<offset)
+}
+
+func (c Cell) leadingNonZeroDigit() digit {
+	for r := 1; r <= c.Resolution(); r++ {
+		if d := c.digit(r); d != centerDigit {
+			return d
+		}
+	}
+
+	return centerDigit
+}
+
+func (c Cell) rotate60ccw() Cell {
+	for r := 1; r <= c.Resolution(); r++ {
+		c = c.setDigit(r, c.digit(r).rotate60ccw())
+	}
+
+	return c
+}
+
+func (c Cell) rotate60cw() Cell {
+	for r := 1; r <= c.Resolution(); r++ {
+		c = c.setDigit(r, c.digit(r).rotate60cw())
+	}
+
+	return c
+}
+
+// rotatePent60ccw rotates pentagon cell skipping the deleted k-axes sequence
+func (c Cell) rotatePent60ccw() Cell {
+	foundFirstNonZeroDigit := false
+	for r := 1; r <= c.Resolution(); r++ {
+		c = c.setDigit(r, c.digit(r).rotate60ccw())
+
+		if !foundFirstNonZeroDigit && c.digit(r) != centerDigit {
+			foundFirstNonZeroDigit = true
+
+			if c.leadingNonZeroDigit() == kAxesDigit {
+				c = c.rotate60ccw()
+			}
+		}
+	}
+
+	return c
+}
+
+func newCell(res int, baseCell int) Cell {
+	return Cell(uint64(cellMode)<<modeOffset | uint64(res)<<resOffset | uint64(baseCell)<<baseCellOffset | initIndex)
+}
+
+// faceIJKToCell returns cell with face coordinate at resolution
+func faceIJKToCell(f faceIJK, res int) Cell {
+	if res == 0 {
+		baseCell, _ := faceIJKToBaseCell(f)
+		return newCell(0, baseCell)
+	}
+
+	// build index from finest resolution up
+	coord := f.coord
+	digits := make([]digit, res+1)
+	for r := res - 1; r >= 0; r-- {
+		lastCoord := coord
+
+		var lastCenter coordIJK
+		if isResolutionClassIII(r + 1) {
+			coord = coord.upAp7()
+			lastCenter = coord.downAp7()
+		} else {
+			coord = coord.upAp7r()
+			lastCenter = coord.downAp7r()
+		}
+
+		digits[r+1] = lastCoord.sub(lastCenter).toDigit()
+	}
+
+	baseFIJK := faceIJK{face: f.face, coord: coord}
+	baseCell, numRots := faceIJKToBaseCell(baseFIJK)
+
+	c := newCell(res, baseCell)
+	for r := 1; r <= res; r++ {
+		c = c.setDigit(r, digits[r])
+	}
+
+	if baseCells[baseCell].isPentagon {
+		// force rotation out of missing k-axes sub-sequence
+		if c.leadingNonZeroDigit() == kAxesDigit {
+			if isBaseCellCwOffset(baseCell, f.face) {
+				c = c.rotate60cw()
+			} else {
+				c = c.rotate60ccw()
+			}
+		}
+
+		for i := 0; i < numRots; i++ {
+			c = c.rotatePent60ccw()
+		}
+	} else {
+		for i := 0; i < numRots; i++ {
+			c = c.rotate60ccw()
+		}
+	}
+
+	return c
+}
+
+// toFaceIJKWithInitializedFIJK moves base cell coordinate down to cell resolution,
+// returns false if cell can't be on other face than base cell home face
+func (c Cell) toFaceIJKWithInitializedFIJK(f *faceIJK) bool {
+	res := c.Resolution()
+
+	possibleOverage := true
+	if !baseCells[c.BaseCell()].isPentagon && (res == 0 || f.coord == coordIJK{}) {
+		possibleOverage = false
+	}
+
+	for r := 1; r <= res; r++ {
+		if isResolutionClassIII(r) {
+			f.coord = f.coord.downAp7()
+		} else {
+			f.coord = f.coord.downAp7r()
+		}
+
+		f.coord = f.coord.neighbor(c.digit(r))
+	}
+
+	return possibleOverage
+}
+
+// toFaceIJK returns face coordinate of cell center
+func (c Cell) toFaceIJK() faceIJK {
+	baseCell := c.BaseCell()
+
+	// adjust for the pentagon missing sequence, all of sub-sequence 5 needs to be adjusted
+	if baseCells[baseCell].isPentagon && c.leadingNonZeroDigit() == ikAxesDigit {
+		c = c.rotate60cw()
+	}
+
+	f := baseCells[baseCell].homeFIJK
+	if !c.toFaceIJKWithInitializedFIJK(&f) {
+		return f
+	}
+
+	// cell can be on other face
+	origCoord := f.coord
+
+	res := c.Resolution()
+	if isResolutionClassIII(res) {
+		// Class II grid is needed to find overage
+		f.coord = f.coord.downAp7r()
+		res++
+	}
+
+	pentLeading4 := baseCells[baseCell].isPentagon && c.leadingNonZeroDigit() == iAxesDigit
+	if f.adjustOverageClassII(res, pentLeading4, false) != noOverage {
+		// pentagon base cells can have overage of the second face
+		if baseCells[baseCell].isPentagon {
+			for f.adjustOverageClassII(res, false, false) != noOverage {
+			}
+		}
+
+		if res != c.Resolution() {
+			f.coord = f.coord.upAp7r()
+		}
+	} else if res != c.Resolution() {
+		f.coord = origCoord
+	}
+
+	return f
+}
+
+// toVerts returns cell vertices in substrate grid, adjRes is Class II resolution of substrate
+func (f faceIJK) toVerts(res int, numVerts int) ([]faceIJK, int) {
+	// vertices of origin-centered cell listed counter-clockwise from i-axes,
+	// Class II on aperture 33r substrate grid and Class III on aperture 33r7r substrate grid
+	vertsCII := [numHexVerts]coordIJK{{2, 1, 0}, {1, 2, 0}, {0, 2, 1}, {0, 1, 2}, {1, 0, 2}, {2, 0, 1}}
+	vertsCIII := [numHexVerts]coordIJK{{5, 4, 0}, {1, 5, 0}, {0, 5, 4}, {0, 1, 5}, {4, 0, 5}, {5, 0, 1}}
+
+	verts := vertsCII
+	if isResolutionClassIII(res) {
+		verts = vertsCIII
+	}
+
+	// center in substrate grid
+	center := f.coord.downAp3().downAp3r()
+	adjRes := res
+	if isResolutionClassIII(res) {
+		center = center.downAp7r()
+		adjRes++
+	}
+
+	fijkVerts := make([]faceIJK, numVerts)
+	for v := range fijkVerts {
+		fijkVerts[v] = faceIJK{face: f.face, coord: center.add(verts[v]).normalize()}
+	}
+
+	return fijkVerts, adjRes
+}
+
+// faceEdge returns hex2d vertices of face edge between face and its neighbor
+func faceEdgeVerts(adjRes int, dir int) (vec2d, vec2d) {
+	maxDim := float64(maxDimByCIIRes(adjRes))
+	v0 := vec2d{3 * maxDim, 0}
+	v1 := vec2d{-1.5 * maxDim, 3 * sqrt3Over2 * maxDim}
+	v2 := vec2d{-1.5 * maxDim, -3 * sqrt3Over2 * maxDim}
+
+	switch dir {
+	case ij:
+		return v0, v1
+	case jk:
+		return v1, v2
+	default:
+		return v2, v0
+	}
+}
+
+// toBoundary returns hexagon vertices, Class III cell edges crossing icosahedron
+// edges get additional vertices on them
+func (f faceIJK) toBoundary(res int) []latLng {
+	fijkVerts, adjRes := f.toVerts(res, numHexVerts)
+
+	boundary := make([]latLng, 0, numHexVerts*2)
+	lastFace := -1
+	lastOverage := noOverage
+	for vert := 0; vert < numHexVerts+1; vert++ {
+		v := vert % numHexVerts
+
+		fijk := fijkVerts[v]
+		ov := fijk.adjustOverageClassII(adjRes, false, true)
+
+		// each face is a different projection plane, so edge crossing icosahedron edge
+		// gets vertex on intersection, Class II cell edges have vertices on face edges
+		if isResolutionClassIII(res) && vert > 0 && fijk.face != lastFace && lastOverage != faceEdge {
+			lastV := (v + 5) % numHexVerts
+			orig2d0 := fijkVerts[lastV].coord.toHex2d()
+			orig2d1 := fijkVerts[v].coord.toHex2d()
+
+			face2 := lastFace
+			if lastFace == f.face {
+				face2 = fijk.face
+			}
+
+			edge0, edge1 := faceEdgeVerts(adjRes, adjacentFaceDir[f.face][face2])
+			inter := intersect(orig2d0, orig2d1, edge0, edge1)
+
+			// intersection at hexagon vertex doesn't need additional vertex
+			if !orig2d0.almostEquals(inter) && !orig2d1.almostEquals(inter) {
+				boundary = append(boundary, hex2dToGeo(inter, f.face, adjRes, true))
+			}
+		}
+
+		// last iteration only checks intersection on last edge
+		if vert < numHexVerts {
+			boundary = append(boundary, hex2dToGeo(fijk.coord.toHex2d(), fijk.face, adjRes, true))
+		}
+
+		lastFace = fijk.face
+		lastOverage = ov
+	}
+
+	return boundary
+}
+
+// pentToBoundary returns pentagon vertices, all Class III pentagon edges cross icosahedron edges
+func (f faceIJK) pentToBoundary(res int) []latLng {
+	fijkVerts, adjRes := f.toVerts(res, numPentVerts)
+
+	boundary := make([]latLng, 0, numPentVerts*2)
+	var lastFIJK faceIJK
+	for vert := 0; vert < numPentVerts+1; vert++ {
+		v := vert % numPentVerts
+
+		fijk := fijkVerts[v]
+		fijk.adjustPentVertOverage(adjRes)
+
+		if isResolutionClassIII(res) && vert > 0 {
+			// last vertex on current face
+			orig2d0 := lastFIJK.coord.toHex2d()
+
+			orient := faceNeighbors[fijk.face][adjacentFaceDir[fijk.face][lastFIJK.face]]
+			tmp := faceIJK{face: orient.face, coord: fijk.coord}
+			for i := 0; i < orient.ccwRot60; i++ {
+				tmp.coord = tmp.coord.rotate60ccw()
+			}
+			tmp.coord = tmp.coord.add(orient.translate.scale(unitScaleByCIIRes(adjRes) * 3)).normalize()
+			orig2d1 := tmp.coord.toHex2d()
+
+			edge0, edge1 := faceEdgeVerts(adjRes, adjacentFaceDir[tmp.face][fijk.face])
+			inter := intersect(orig2d0, orig2d1, edge0, edge1)
+			boundary = append(boundary, hex2dToGeo(inter, tmp.face, adjRes, true))
+		}
+
+		if vert < numPentVerts {
+			boundary = append(boundary, hex2dToGeo(fijk.coord.toHex2d(), fijk.face, adjRes, true))
+		}
+
+		lastFIJK = fijk
+	}
+
+	return boundary
+}
+
+// faceIJKToBaseCell returns base cell at resolution 0 face coordinate and number of
+// counter-clockwise rotations from face to base cell home face
+func faceIJKToBaseCell(f faceIJK) (int, int) {
+	c := f.coord
+	if c.i > maxFaceCoord || c.j > maxFaceCoord || c.k > maxFaceCoord {
+		return invalidBaseCell, 0
+	}
+
+	rot := faceIJKBaseCells[f.face][c.i][c.j][c.k]
+
+	return rot.baseCell, rot.ccwRot60
+}
+
+func isBaseCellCwOffset(baseCell int, face int) bool {
+	offset := baseCells[baseCell].cwOffsetPent
+
+	return offset[0] == face || offset[1] == face
+}
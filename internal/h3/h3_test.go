@@ -0,0 +1,144 @@
+package h3
+
+import (
+	"math"
+	"testing"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+)
+
+func TestLatLngToCell(t *testing.T) {
+	tests := []struct {
+		lat, lng float64
+		res      int
+		want     string
+	}{
+		{37.775938728915946, -122.41795063018799, 9, "8928308280fffff"},
+		{37.3457933, -121.9763759, 5, "85283473fffffff"},
+		{79.2423985098, 38.0234070080, 0, "8001fffffffffff"},
+	}
+
+	for _, test := range tests {
+		cell, err := LatLngToCell(test.lat, test.lng, test.res)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if cell.String() != test.want {
+			t.Errorf("%f, %f at %d: got %s, want %s", test.lat, test.lng, test.res, cell, test.want)
+		}
+	}
+}
+
+func TestCellLatLng(t *testing.T) {
+	cell, err := ParseCell("8928308280fffff")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lat, lng := cell.LatLng()
+	if math.Abs(lat-37.77670234943567) > 1e-9 || math.Abs(lng+122.41845932318311) > 1e-9 {
+		t.Errorf("got center %f, %f", lat, lng)
+	}
+}
+
+func TestCellBoundary(t *testing.T) {
+	cell, err := ParseCell("85283473fffffff")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := orb.Ring{
+		{-121.91508032705622, 37.271355866731895},
+		{-121.86222328902491, 37.353926450852256},
+		{-121.9235499963016, 37.42834118609435},
+		{-122.0377349642703, 37.42012867767778},
+		{-122.09042892904395, 37.33755608435298},
+		{-122.02910130919, 37.26319797461824},
+		{-121.91508032705622, 37.271355866731895},
+	}
+
+	got := cell.Boundary()
+	if len(got) != len(want) {
+		t.Fatalf("got %d vertices, want %d", len(got), len(want))
+	}
+
+	for i := range want {
+		if math.Abs(got[i][0]-want[i][0]) > 1e-9 || math.Abs(got[i][1]-want[i][1]) > 1e-9 {
+			t.Errorf("vertex %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestBaseCells(t *testing.T) {
+	cells := make(map[Cell]bool)
+	pentagons := 0
+
+	for i := 0; i < 20000; i++ {
+		// spiral points cover sphere evenly
+		lat := math.Asin(1-2*(float64(i)+0.5)/20000) * 180 / math.Pi
+		lng := math.Mod(float64(i)*180*(3-math.Sqrt(5)), 360) - 180
+
+		cell, err := LatLngToCell(lat, lng, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !cells[cell] && cell.IsPentagon() {
+			pentagons++
+		}
+		cells[cell] = true
+	}
+
+	if len(cells) != numBaseCells || pentagons != 12 {
+		t.Errorf("got %d base cells and %d pentagons", len(cells), pentagons)
+	}
+}
+
+func TestCellRoundTrip(t *testing.T) {
+	for res := 0; res <= MaxResolution; res++ {
+		for i := 0; i < 2000; i++ {
+			lat := math.Asin(1-2*(float64(i)+0.5)/2000) * 180 / math.Pi
+			lng := math.Mod(float64(i)*180*(3-math.Sqrt(5)), 360) - 180
+
+			cell, err := LatLngToCell(lat, lng, res)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !cell.IsValid() || cell.Resolution() != res {
+				t.Fatalf("%f, %f at %d: invalid cell %s", lat, lng, res, cell)
+			}
+
+			centerLat, centerLng := cell.LatLng()
+			center, err := LatLngToCell(centerLat, centerLng, res)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if center != cell {
+				t.Fatalf("%f, %f at %d: center of %s is in %s", lat, lng, res, cell, center)
+			}
+
+			boundary := cell.Boundary()
+			verts := len(boundary) - 1
+			if verts < 5 || verts > 10 {
+				t.Fatalf("%s has %d vertices", cell, verts)
+			}
+
+			// planar check is valid for small cells far from antimeridian
+			if res >= 6 && math.Abs(lng) < 179 && !planar.RingContains(boundary, orb.Point{lng, lat}) {
+				t.Fatalf("%f, %f at %d: point is outside of %s", lat, lng, res, cell)
+			}
+		}
+	}
+}
+
+func TestParseCell(t *testing.T) {
+	for _, s := range []string{"", "zz", "8928308280fffff0", "0"} {
+		if _, err := ParseCell(s); err == nil {
+			t.Errorf("%q is parsed", s)
+		}
+	}
+}
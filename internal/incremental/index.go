@@ -0,0 +1,112 @@
+package incremental
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+)
+
+type entry struct {
+	FetchedAt       time.Time  `json:"fetched_at"`
+	ClusterMinPrice float64    `json:"cluster_min_price"`
+	ClusterMaxPrice float64    `json:"cluster_max_price"`
+	Offer           cian.Offer `json:"offer"`
+}
+
+// Index keeps last fetched offers with cluster prices seen at fetch time
+type Index struct {
+	path    string
+	entries map[int64]*entry
+}
+
+func Load(path string) (*Index, error) {
+	index := &Index{
+		path:    path,
+		entries: make(map[int64]*entry),
+	}
+
+	indexJSON, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't read offers index: %w", err)
+	}
+
+	err = json.Unmarshal(indexJSON, &index.entries)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse offers index: %w", err)
+	}
+
+	return index, nil
+}
+
+// GetIDsToFetch returns new offers, offers with changed cluster price and offers older than refresh age
+func (i *Index) GetIDsToFetch(ids []int64, prices map[int64]cian.ClusterPrices, now time.Time, refreshAge time.Duration) []int64 {
+	idsToFetch := make([]int64, 0)
+	for _, id := range ids {
+		entry, ok := i.entries[id]
+		if !ok ||
+			entry.ClusterMinPrice != prices[id].MinPrice ||
+			entry.ClusterMaxPrice != prices[id].MaxPrice ||
+			now.Sub(entry.FetchedAt) > refreshAge {
+			idsToFetch = append(idsToFetch, id)
+		}
+	}
+
+	return idsToFetch
+}
+
+func (i *Index) Update(offers []cian.Offer, prices map[int64]cian.ClusterPrices, now time.Time) {
+	for _, offer := range offers {
+		i.entries[offer.CianID] = &entry{
+			FetchedAt:       now,
+			ClusterMinPrice: prices[offer.CianID].MinPrice,
+			ClusterMaxPrice: prices[offer.CianID].MaxPrice,
+			Offer:           offer,
+		}
+	}
+}
+
+// GetOffers returns indexed offers by ids and removes other offers from index
+func (i *Index) GetOffers(ids []int64) []cian.Offer {
+	entries := make(map[int64]*entry, len(ids))
+	offers := make([]cian.Offer, 0, len(ids))
+	for _, id := range ids {
+		entry, ok := i.entries[id]
+		if !ok {
+			continue
+		}
+
+		entries[id] = entry
+		offers = append(offers, entry.Offer)
+	}
+
+	i.entries = entries
+
+	return offers
+}
+
+func (i *Index) Save() error {
+	indexJSON, err := json.Marshal(i.entries)
+	if err != nil {
+		return fmt.Errorf("can't marshal offers index: %w", err)
+	}
+
+	tmpPath := i.path + ".tmp"
+	err = os.WriteFile(tmpPath, indexJSON, 0o644)
+	if err != nil {
+		return fmt.Errorf("can't write offers index: %w", err)
+	}
+
+	err = os.Rename(tmpPath, i.path)
+	if err != nil {
+		return fmt.Errorf("can't replace offers index: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package interpolation
+
+import (
+	"errors"
+	"math"
+)
+
+// IDW is an inverse distance weighting by nearest samples
+type IDW struct {
+	points    *pointSet
+	power     float64
+	neighbors int
+}
+
+func NewIDW(samples []Sample, power float64, neighbors int) (*IDW, error) {
+	if neighbors < 1 {
+		return nil, errors.New("neighbors must be positive")
+	}
+
+	points, err := newPointSet(samples, neighbors)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IDW{
+		points:    points,
+		power:     power,
+		neighbors: neighbors,
+	}, nil
+}
+
+func (idw *IDW) Predict(lng, lat float64) (float64, error) {
+	x, y := idw.points.xy(lng, lat)
+
+	neighbors := idw.points.nearest(x, y, idw.neighbors)
+	if len(neighbors) == 0 {
+		return 0, errors.New("no samples")
+	}
+
+	weightSum := 0.0
+	valueSum := 0.0
+	for _, n := range neighbors {
+		// Sample at the same point has infinite weight
+		if n.distance < 1e-6 {
+			return idw.points.values[n.index], nil
+		}
+
+		weight := 1 / math.Pow(n.distance, idw.power)
+		weightSum += weight
+		valueSum += weight * idw.points.values[n.index]
+	}
+
+	// Weights of very far samples can be rounded to zero
+	if weightSum == 0 {
+		return 0, errors.New("samples are too far from point")
+	}
+
+	return valueSum / weightSum, nil
+}
@@ -0,0 +1,203 @@
+package interpolation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/mishannn/cianparser-go/internal/geo"
+)
+
+// Sample is a known value at point, e.g. offer price per meter
+type Sample struct {
+	Lng   float64
+	Lat   float64
+	Value float64
+}
+
+// Interpolator estimates value at arbitrary point
+type Interpolator interface {
+	Predict(lng, lat float64) (float64, error)
+}
+
+// Builder makes interpolator from samples, it is used by cross-validation
+type Builder func(samples []Sample) (Interpolator, error)
+
+type neighbor struct {
+	index    int
+	distance float64
+}
+
+// pointSet keeps samples on local plane in meters with bucket index for neighbors search
+type pointSet struct {
+	lat0Cos  float64
+	xs       []float64
+	ys       []float64
+	values   []float64
+	cellSize float64
+	buckets  map[[2]int][]int
+	maxRing  int
+}
+
+func newPointSet(samples []Sample, neighbors int) (*pointSet, error) {
+	if len(samples) == 0 {
+		return nil, errors.New("no samples")
+	}
+
+	latSum := 0.0
+	for _, sample := range samples {
+		latSum += sample.Lat
+	}
+
+	s := &pointSet{
+		lat0Cos: math.Cos(latSum / float64(len(samples)) * math.Pi / 180),
+		xs:      make([]float64, len(samples)),
+		ys:      make([]float64, len(samples)),
+		values:  make([]float64, len(samples)),
+		buckets: make(map[[2]int][]int),
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for i, sample := range samples {
+		s.xs[i], s.ys[i] = s.xy(sample.Lng, sample.Lat)
+		s.values[i] = sample.Value
+
+		minX, maxX = min(minX, s.xs[i]), max(maxX, s.xs[i])
+		minY, maxY = min(minY, s.ys[i]), max(maxY, s.ys[i])
+	}
+
+	// Bucket has about as many samples as needed for one prediction
+	area := max(maxX-minX, 1) * max(maxY-minY, 1)
+	s.cellSize = math.Sqrt(area * float64(max(neighbors, 1)) / float64(len(samples)))
+	s.maxRing = int(math.Ceil(max(maxX-minX, maxY-minY)/s.cellSize)) + 1
+
+	for i := range samples {
+		key := s.bucket(s.xs[i], s.ys[i])
+		s.buckets[key] = append(s.buckets[key], i)
+	}
+
+	return s, nil
+}
+
+func (s *pointSet) xy(lng, lat float64) (float64, float64) {
+	return lng * geo.MetersPerLatDegree * s.lat0Cos, lat * geo.MetersPerLatDegree
+}
+
+func (s *pointSet) bucket(x, y float64) [2]int {
+	return [2]int{int(math.Floor(x / s.cellSize)), int(math.Floor(y / s.cellSize))}
+}
+
+func (s *pointSet) distance(i, j int) float64 {
+	return math.Hypot(s.xs[i]-s.xs[j], s.ys[i]-s.ys[j])
+}
+
+// nearest returns up to k samples closest to point sorted by distance,
+// rings of buckets are checked until kth distance is covered, points far from samples
+// are not covered by rings and all samples are checked for them
+func (s *pointSet) nearest(x, y float64, k int) []neighbor {
+	center := s.bucket(x, y)
+	candidates := make([]neighbor, 0, k*2)
+
+	for ring := 0; ring <= s.maxRing; ring++ {
+		for dx := -ring; dx <= ring; dx++ {
+			for dy := -ring; dy <= ring; dy++ {
+				if max(abs(dx), abs(dy)) != ring {
+					continue
+				}
+
+				for _, i := range s.buckets[[2]int{center[0] + dx, center[1] + dy}] {
+					candidates = append(candidates, neighbor{index: i, distance: math.Hypot(s.xs[i]-x, s.ys[i]-y)})
+				}
+			}
+		}
+
+		if len(candidates) >= k {
+			sort.Slice(candidates, func(i, j int) bool {
+				return candidates[i].distance < candidates[j].distance
+			})
+
+			// Points of next rings are farther than ring * cellSize
+			if candidates[k-1].distance <= float64(ring)*s.cellSize {
+				return candidates[:k]
+			}
+		}
+	}
+
+	candidates = make([]neighbor, len(s.xs))
+	for i := range s.xs {
+		candidates[i] = neighbor{index: i, distance: math.Hypot(s.xs[i]-x, s.ys[i]-y)}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	return candidates[:min(k, len(candidates))]
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// Validation has errors of predictions for samples which were not used to build interpolator
+type Validation struct {
+	Count int
+	RMSE  float64
+	MAE   float64
+}
+
+// CrossValidate splits samples to folds randomly with fixed seed,
+// every fold is predicted by interpolator built from other folds
+func CrossValidate(samples []Sample, folds int, build Builder) (Validation, error) {
+	if folds < 2 || folds > len(samples) {
+		return Validation{}, fmt.Errorf("folds must be from 2 to samples count")
+	}
+
+	foldIDs := make([]int, len(samples))
+	for i, j := range rand.New(rand.NewSource(1)).Perm(len(samples)) {
+		foldIDs[j] = i % folds
+	}
+
+	var validation Validation
+	squaredSum := 0.0
+	absSum := 0.0
+	for fold := 0; fold < folds; fold++ {
+		train := make([]Sample, 0, len(samples))
+		test := make([]Sample, 0, len(samples)/folds+1)
+		for i, sample := range samples {
+			if foldIDs[i] == fold {
+				test = append(test, sample)
+			} else {
+				train = append(train, sample)
+			}
+		}
+
+		interpolator, err := build(train)
+		if err != nil {
+			return Validation{}, fmt.Errorf("can't build interpolator for fold %d: %w", fold, err)
+		}
+
+		for _, sample := range test {
+			value, err := interpolator.Predict(sample.Lng, sample.Lat)
+			if err != nil {
+				return Validation{}, fmt.Errorf("can't predict fold %d: %w", fold, err)
+			}
+
+			diff := value - sample.Value
+			squaredSum += diff * diff
+			absSum += math.Abs(diff)
+			validation.Count++
+		}
+	}
+
+	validation.RMSE = math.Sqrt(squaredSum / float64(validation.Count))
+	validation.MAE = absSum / float64(validation.Count)
+
+	return validation, nil
+}
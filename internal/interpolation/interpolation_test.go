@@ -0,0 +1,296 @@
+package interpolation
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func getRandomSamples(count int, seed int64) []Sample {
+	random := rand.New(rand.NewSource(seed))
+
+	samples := make([]Sample, count)
+	for i := range samples {
+		samples[i] = Sample{
+			Lng:   49.0 + random.Float64()*0.3,
+			Lat:   55.7 + random.Float64()*0.2,
+			Value: random.Float64() * 1000,
+		}
+	}
+
+	return samples
+}
+
+// getExponentialField returns samples of gaussian field with exponential variogram without nugget
+func getExponentialField(t *testing.T, count int, variogram Variogram) []Sample {
+	t.Helper()
+
+	samples := getRandomSamples(count, 2)
+	points, err := newPointSet(samples, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	covariance := mat.NewSymDense(count, nil)
+	for i := 0; i < count; i++ {
+		for j := i; j < count; j++ {
+			c := variogram.Sill * math.Exp(-3*points.distance(i, j)/variogram.Range)
+			if i == j {
+				c += variogram.Sill * 1e-9
+			}
+			covariance.SetSym(i, j, c)
+		}
+	}
+
+	var cholesky mat.Cholesky
+	if !cholesky.Factorize(covariance) {
+		t.Fatal("covariance is not positive definite")
+	}
+	var lower mat.TriDense
+	cholesky.LTo(&lower)
+
+	random := rand.New(rand.NewSource(3))
+	noise := mat.NewVecDense(count, nil)
+	for i := 0; i < count; i++ {
+		noise.SetVec(i, random.NormFloat64())
+	}
+
+	var field mat.VecDense
+	field.MulVec(&lower, noise)
+	for i := range samples {
+		samples[i].Value = 1000 + field.AtVec(i)
+	}
+
+	return samples
+}
+
+func TestIDWExactAtSamples(t *testing.T) {
+	samples := getRandomSamples(100, 1)
+
+	tests := []struct {
+		power     float64
+		neighbors int
+	}{
+		{1, 1},
+		{2, 4},
+		{2, 8},
+		{3, 100},
+	}
+
+	for _, test := range tests {
+		idw, err := NewIDW(samples, test.power, test.neighbors)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, sample := range samples {
+			value, err := idw.Predict(sample.Lng, sample.Lat)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if value != sample.Value {
+				t.Errorf("power %.0f, %d neighbors: got %f at sample, want %f", test.power, test.neighbors, value, sample.Value)
+			}
+		}
+	}
+
+	if _, err := NewIDW(samples, 2, 0); err == nil {
+		t.Error("idw without neighbors is built")
+	}
+}
+
+func TestIDWBetweenSamples(t *testing.T) {
+	samples := []Sample{
+		{Lng: 49.10, Lat: 55.78, Value: 100},
+		{Lng: 49.12, Lat: 55.78, Value: 300},
+	}
+
+	idw, err := NewIDW(samples, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := idw.Predict(49.11, 55.78)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(value-200) > 1e-6 {
+		t.Errorf("got %f in the middle, want 200", value)
+	}
+}
+
+func TestKrigingKnownVariogram(t *testing.T) {
+	known := Variogram{Sill: 400, Range: 8000}
+	samples := getExponentialField(t, 300, known)
+
+	kriging, err := NewOrdinaryKriging(samples, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fitted := kriging.Variogram()
+	if total := fitted.Nugget + fitted.Sill; total < known.Sill/3 || total > known.Sill*3 {
+		t.Errorf("got total sill %f, want about %f", total, known.Sill)
+	}
+	if fitted.Nugget > fitted.Sill {
+		t.Errorf("got nugget %f bigger than sill %f for field without nugget", fitted.Nugget, fitted.Sill)
+	}
+	if fitted.Range < known.Range/3 || fitted.Range > known.Range*3 {
+		t.Errorf("got range %f, want about %f", fitted.Range, known.Range)
+	}
+
+	for _, sample := range samples {
+		value, err := kriging.Predict(sample.Lng, sample.Lat)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if math.Abs(value-sample.Value) > 1e-3*math.Sqrt(known.Sill) {
+			t.Errorf("got %f at sample, want %f", value, sample.Value)
+		}
+	}
+
+	// kriging must be better than mean of samples
+	validation, err := CrossValidate(samples, 5, func(samples []Sample) (Interpolator, error) {
+		return NewOrdinaryKriging(samples, 16)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mean := 0.0
+	for _, sample := range samples {
+		mean += sample.Value / float64(len(samples))
+	}
+	variance := 0.0
+	for _, sample := range samples {
+		variance += (sample.Value - mean) * (sample.Value - mean) / float64(len(samples))
+	}
+
+	if validation.RMSE >= math.Sqrt(variance) {
+		t.Errorf("got kriging rmse %f, not better than standard deviation %f", validation.RMSE, math.Sqrt(variance))
+	}
+}
+
+// recorder remembers train sets of cross-validation and predicts zero
+type recorder struct {
+	trains [][]Sample
+}
+
+func (r *recorder) Predict(lng, lat float64) (float64, error) {
+	return 0, nil
+}
+
+func TestCrossValidateFolds(t *testing.T) {
+	samples := getRandomSamples(23, 1)
+
+	for _, folds := range []int{2, 5, 23} {
+		r := &recorder{}
+		validation, err := CrossValidate(samples, folds, func(train []Sample) (Interpolator, error) {
+			r.trains = append(r.trains, train)
+			return r, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if validation.Count != len(samples) {
+			t.Errorf("%d folds: got %d predictions, want %d", folds, validation.Count, len(samples))
+		}
+		if len(r.trains) != folds {
+			t.Fatalf("%d folds: got %d interpolators", folds, len(r.trains))
+		}
+
+		// every sample is in test set of exactly one fold
+		excluded := make(map[Sample]int)
+		testSizes := make([]int, 0, folds)
+		for _, train := range r.trains {
+			inTrain := make(map[Sample]bool, len(train))
+			for _, sample := range train {
+				inTrain[sample] = true
+			}
+
+			for _, sample := range samples {
+				if !inTrain[sample] {
+					excluded[sample]++
+				}
+			}
+			testSizes = append(testSizes, len(samples)-len(train))
+		}
+
+		for _, sample := range samples {
+			if excluded[sample] != 1 {
+				t.Errorf("%d folds: sample %v is tested %d times", folds, sample, excluded[sample])
+			}
+		}
+
+		sort.Ints(testSizes)
+		if testSizes[len(testSizes)-1]-testSizes[0] > 1 {
+			t.Errorf("%d folds: got unbalanced test sizes %v", folds, testSizes)
+		}
+	}
+
+	for _, folds := range []int{0, 1, len(samples) + 1} {
+		_, err := CrossValidate(samples, folds, func(train []Sample) (Interpolator, error) {
+			return &recorder{}, nil
+		})
+		if err == nil {
+			t.Errorf("%d folds for %d samples are accepted", folds, len(samples))
+		}
+	}
+}
+
+func TestPointSetNearest(t *testing.T) {
+	samples := getRandomSamples(500, 1)
+	// dense group makes buckets uneven
+	for i := 0; i < 100; i++ {
+		samples = append(samples, Sample{Lng: 49.15 + float64(i)*1e-5, Lat: 55.8, Value: float64(i)})
+	}
+
+	random := rand.New(rand.NewSource(4))
+	queries := [][2]float64{
+		// far points are outside of bucket rings
+		{37.6, 55.75},
+		{49.15, 60.0},
+		{60.0, 50.0},
+		{49.15, 55.8},
+	}
+	for i := 0; i < 100; i++ {
+		queries = append(queries, [2]float64{48.9 + random.Float64()*0.5, 55.6 + random.Float64()*0.4})
+	}
+
+	for _, k := range []int{1, 5, 16, len(samples) + 10} {
+		points, err := newPointSet(samples, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, query := range queries {
+			x, y := points.xy(query[0], query[1])
+
+			want := make([]float64, len(samples))
+			for i := range samples {
+				want[i] = math.Hypot(points.xs[i]-x, points.ys[i]-y)
+			}
+			sort.Float64s(want)
+			want = want[:min(k, len(want))]
+
+			got := points.nearest(x, y, k)
+			if len(got) != len(want) {
+				t.Fatalf("k %d, %v: got %d neighbors, want %d", k, query, len(got), len(want))
+			}
+
+			for i := range want {
+				if got[i].distance != want[i] {
+					t.Errorf("k %d, %v: got %d-th distance %f, want %f", k, query, i, got[i].distance, want[i])
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package interpolation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+const (
+	variogramBins     = 15
+	variogramPairs    = 200000
+	variogramRanges   = 30
+	minNuggetFraction = 1e-6
+)
+
+// Variogram is an exponential model: nugget + sill * (1 - exp(-3h / range))
+type Variogram struct {
+	Nugget float64
+	Sill   float64
+	Range  float64
+}
+
+// gamma is a semivariance of two different samples, it has nugget even at zero distance
+func (v Variogram) gamma(distance float64) float64 {
+	return v.Nugget + v.Sill*(1-math.Exp(-3*distance/v.Range))
+}
+
+// Kriging is an ordinary kriging by nearest samples with fitted exponential variogram
+type Kriging struct {
+	points    *pointSet
+	neighbors int
+	variogram Variogram
+}
+
+func NewOrdinaryKriging(samples []Sample, neighbors int) (*Kriging, error) {
+	if neighbors < 2 {
+		return nil, errors.New("kriging needs at least 2 neighbors")
+	}
+
+	points, err := newPointSet(samples, neighbors)
+	if err != nil {
+		return nil, err
+	}
+
+	variogram, err := fitVariogram(points)
+	if err != nil {
+		return nil, fmt.Errorf("can't fit variogram: %w", err)
+	}
+
+	return &Kriging{
+		points:    points,
+		neighbors: neighbors,
+		variogram: variogram,
+	}, nil
+}
+
+// Variogram returns fitted model
+func (k *Kriging) Variogram() Variogram {
+	return k.variogram
+}
+
+// fitVariogram builds empirical semivariogram from random pairs and fits exponential model
+// by weighted least squares, nugget and sill are linear for fixed range so only range is searched
+func fitVariogram(points *pointSet) (Variogram, error) {
+	n := len(points.values)
+	if n < 3 {
+		return Variogram{}, errors.New("not enough samples")
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for i := range points.values {
+		minX, maxX = min(minX, points.xs[i]), max(maxX, points.xs[i])
+		minY, maxY = min(minY, points.ys[i]), max(maxY, points.ys[i])
+	}
+
+	// Semivariance is unreliable for lags longer than half of extent
+	maxLag := math.Hypot(maxX-minX, maxY-minY) / 2
+	if maxLag == 0 {
+		return Variogram{}, errors.New("all samples are at one point")
+	}
+
+	counts := make([]float64, variogramBins)
+	sums := make([]float64, variogramBins)
+	lags := make([]float64, variogramBins)
+
+	random := rand.New(rand.NewSource(1))
+	for p := 0; p < min(variogramPairs, n*(n-1)/2); p++ {
+		i, j := random.Intn(n), random.Intn(n)
+		if i == j {
+			continue
+		}
+
+		distance := points.distance(i, j)
+		if distance >= maxLag {
+			continue
+		}
+
+		bin := int(distance / maxLag * variogramBins)
+		diff := points.values[i] - points.values[j]
+		counts[bin]++
+		sums[bin] += diff * diff / 2
+		lags[bin] += distance
+	}
+
+	best := Variogram{}
+	bestError := math.Inf(1)
+	for r := 1; r <= variogramRanges; r++ {
+		modelRange := maxLag * float64(r) / variogramRanges
+
+		// Weighted normal equations for gamma = nugget + sill * f(h)
+		var sw, sf, sff, sg, sfg float64
+		for bin := range counts {
+			if counts[bin] == 0 {
+				continue
+			}
+
+			w := counts[bin]
+			f := 1 - math.Exp(-3*lags[bin]/counts[bin]/modelRange)
+			g := sums[bin] / counts[bin]
+			sw += w
+			sf += w * f
+			sff += w * f * f
+			sg += w * g
+			sfg += w * f * g
+		}
+
+		det := sw*sff - sf*sf
+		if sw == 0 || det == 0 {
+			continue
+		}
+
+		sill := (sw*sfg - sf*sg) / det
+		nugget := (sg - sill*sf) / sw
+		sill = max(sill, 0)
+		nugget = max(nugget, 0)
+
+		v := Variogram{Nugget: nugget, Sill: sill, Range: modelRange}
+		squaredError := 0.0
+		for bin := range counts {
+			if counts[bin] == 0 {
+				continue
+			}
+
+			diff := v.gamma(lags[bin]/counts[bin]) - sums[bin]/counts[bin]
+			squaredError += counts[bin] * diff * diff
+		}
+
+		if squaredError < bestError {
+			best = v
+			bestError = squaredError
+		}
+	}
+
+	if math.IsInf(bestError, 1) || best.Nugget+best.Sill == 0 {
+		return Variogram{}, errors.New("samples have no spatial variance")
+	}
+
+	// Samples at the same point make kriging system singular without nugget
+	best.Nugget = max(best.Nugget, (best.Nugget+best.Sill)*minNuggetFraction)
+
+	return best, nil
+}
+
+func (k *Kriging) Predict(lng, lat float64) (float64, error) {
+	x, y := k.points.xy(lng, lat)
+	neighbors := k.points.nearest(x, y, k.neighbors)
+	if len(neighbors) == 0 {
+		return 0, errors.New("no samples")
+	}
+	size := len(neighbors) + 1
+
+	// Semivariances are scaled to total sill to keep system well conditioned, weights do not change
+	scale := k.variogram.Nugget + k.variogram.Sill
+
+	a := mat.NewDense(size, size, nil)
+	b := mat.NewVecDense(size, nil)
+	for i, ni := range neighbors {
+		for j, nj := range neighbors {
+			if i != j {
+				a.Set(i, j, k.variogram.gamma(k.points.distance(ni.index, nj.index))/scale)
+			}
+		}
+
+		a.Set(i, size-1, 1)
+		a.Set(size-1, i, 1)
+
+		// Prediction at sample point returns sample value
+		if ni.distance > 0 {
+			b.SetVec(i, k.variogram.gamma(ni.distance)/scale)
+		}
+	}
+	b.SetVec(size-1, 1)
+
+	var weights mat.VecDense
+	err := weights.SolveVec(a, b)
+	if err != nil {
+		return 0, fmt.Errorf("can't solve kriging system: %w", err)
+	}
+
+	value := 0.0
+	for i, n := range neighbors {
+		value += weights.AtVec(i) * k.points.values[n.index]
+	}
+
+	return value, nil
+}
@@ -0,0 +1,51 @@
+package offerindex
+
+import (
+	"slices"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+)
+
+// Filter has offer attribute conditions, zero values are not checked
+type Filter struct {
+	RoomsCounts      []int
+	Category         string
+	SellerType       string
+	MinPrice         float64
+	MaxPrice         float64
+	MinPricePerMeter float64
+	MaxPricePerMeter float64
+}
+
+func (f Filter) Match(offer cian.Offer) bool {
+	if len(f.RoomsCounts) > 0 && !slices.Contains(f.RoomsCounts, offer.RoomsCount) {
+		return false
+	}
+
+	if f.Category != "" && offer.Category != f.Category {
+		return false
+	}
+
+	if f.SellerType != "" && offer.SellerType() != f.SellerType {
+		return false
+	}
+
+	price := offer.BargainTerms.PriceRur
+	if (f.MinPrice > 0 && price < f.MinPrice) || (f.MaxPrice > 0 && price > f.MaxPrice) {
+		return false
+	}
+
+	if f.MinPricePerMeter > 0 || f.MaxPricePerMeter > 0 {
+		pricePerMeter, err := offer.PricePerMeter()
+		if err != nil {
+			return false
+		}
+
+		if (f.MinPricePerMeter > 0 && pricePerMeter < f.MinPricePerMeter) ||
+			(f.MaxPricePerMeter > 0 && pricePerMeter > f.MaxPricePerMeter) {
+			return false
+		}
+	}
+
+	return true
+}
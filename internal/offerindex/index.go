@@ -0,0 +1,110 @@
+// Package offerindex answers radius, bbox and polygon queries over offers of a run
+// or offers file saved by collect
+package offerindex
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/paulmach/orb"
+	orbgeo "github.com/paulmach/orb/geo"
+	"github.com/twpayne/go-geos"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/geo"
+)
+
+const strTreeNodeCapacity = 10
+
+// Result is an offer found by query, distance is set only by radius query
+type Result struct {
+	Offer          cian.Offer
+	DistanceMeters float64
+}
+
+// Index is an STRtree over offer coordinates
+type Index struct {
+	tree   *geos.STRtree
+	offers []cian.Offer
+}
+
+func New(offers []cian.Offer) (*Index, error) {
+	index := &Index{
+		tree:   geos.DefaultContext.NewSTRtree(strTreeNodeCapacity),
+		offers: offers,
+	}
+
+	for i, offer := range offers {
+		err := index.tree.Insert(geos.NewPointFromXY(offer.Geo.Coordinates.Lng, offer.Geo.Coordinates.Lat), i)
+		if err != nil {
+			return nil, fmt.Errorf("can't index offer %d: %w", offer.CianID, err)
+		}
+	}
+
+	return index, nil
+}
+
+// query returns matching offers with points inside of envelope ordered by offer id
+func (i *Index) query(envelope *geos.Geom, filter Filter) []Result {
+	results := make([]Result, 0)
+	i.tree.Query(envelope, func(value any) {
+		offer := i.offers[value.(int)]
+		if filter.Match(offer) {
+			results = append(results, Result{Offer: offer})
+		}
+	})
+
+	sort.Slice(results, func(a, b int) bool {
+		return results[a].Offer.CianID < results[b].Offer.CianID
+	})
+
+	return results
+}
+
+// Radius returns offers not farther than meters from point ordered by distance
+func (i *Index) Radius(lng, lat, meters float64, filter Filter) []Result {
+	dLat := meters / geo.MetersPerLatDegree
+	dLng := dLat / math.Max(math.Cos(lat*math.Pi/180), 1e-6)
+	envelope := geos.NewBounds(lng-dLng, lat-dLat, lng+dLng, lat+dLat).Geom()
+
+	center := orb.Point{lng, lat}
+	results := make([]Result, 0)
+	for _, result := range i.query(envelope, filter) {
+		coordinates := result.Offer.Geo.Coordinates
+		result.DistanceMeters = orbgeo.DistanceHaversine(center, orb.Point{coordinates.Lng, coordinates.Lat})
+		if result.DistanceMeters <= meters {
+			results = append(results, result)
+		}
+	}
+
+	sort.SliceStable(results, func(a, b int) bool {
+		return results[a].DistanceMeters < results[b].DistanceMeters
+	})
+
+	return results
+}
+
+// Bbox returns offers inside of bounds
+func (i *Index) Bbox(bounds *geos.Bounds, filter Filter) []Result {
+	return i.query(bounds.Geom(), filter)
+}
+
+// Polygon returns offers inside of area or on its border
+func (i *Index) Polygon(geojson string, filter Filter) ([]Result, error) {
+	geom, _, err := geo.NormalizeGeoJSON(geojson)
+	if err != nil {
+		return nil, fmt.Errorf("can't read polygon: %w", err)
+	}
+
+	area := geom.Prepare()
+	results := make([]Result, 0)
+	for _, result := range i.query(geom.Bounds().Geom(), filter) {
+		coordinates := result.Offer.Geo.Coordinates
+		if area.Intersects(geos.NewPointFromXY(coordinates.Lng, coordinates.Lat)) {
+			results = append(results, result)
+		}
+	}
+
+	return results, nil
+}
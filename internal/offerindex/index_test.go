@@ -0,0 +1,175 @@
+package offerindex
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/paulmach/orb"
+	orbgeo "github.com/paulmach/orb/geo"
+	"github.com/twpayne/go-geos"
+
+	"github.com/mishannn/cianparser-go/internal/cian"
+	"github.com/mishannn/cianparser-go/internal/geo"
+)
+
+const centerLng, centerLat = 49.12, 55.78
+
+func newOffer(id int64, lng, lat float64) cian.Offer {
+	return cian.Offer{
+		CianID:       id,
+		Category:     "flatSale",
+		RoomsCount:   2,
+		TotalArea:    "50",
+		BargainTerms: cian.BargainTerms{PriceRur: 5_000_000},
+		Geo:          cian.Geo{Coordinates: cian.Coordinates{Lng: lng, Lat: lat}},
+	}
+}
+
+// offsetOffer returns offer placed north and east of center by meters
+func offsetOffer(id int64, north, east float64) cian.Offer {
+	lat := centerLat + north/geo.MetersPerLatDegree
+	lng := centerLng + east/geo.MetersPerLatDegree/math.Cos(centerLat*math.Pi/180)
+	return newOffer(id, lng, lat)
+}
+
+func getIDs(results []Result) []int64 {
+	ids := make([]int64, len(results))
+	for i, result := range results {
+		ids[i] = result.Offer.CianID
+	}
+	return ids
+}
+
+func TestRadius(t *testing.T) {
+	offers := []cian.Offer{
+		offsetOffer(1, 999, 0),
+		offsetOffer(2, 100, 0),
+		offsetOffer(3, 0, 700),
+		offsetOffer(4, 1001, 0),
+		// inside of search envelope, but farther than radius
+		offsetOffer(5, 800, 800),
+		offsetOffer(6, -500, 0),
+	}
+
+	index, err := New(offers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := index.Radius(centerLng, centerLat, 1000, Filter{})
+	if got, want := getIDs(results), []int64{2, 6, 3, 1}; !slices.Equal(got, want) {
+		t.Fatalf("got offers %v, want %v ordered by distance", got, want)
+	}
+
+	for _, result := range results {
+		coordinates := result.Offer.Geo.Coordinates
+		want := orbgeo.DistanceHaversine(orb.Point{centerLng, centerLat}, orb.Point{coordinates.Lng, coordinates.Lat})
+		if result.DistanceMeters != want {
+			t.Errorf("offer %d: got distance %f, want %f", result.Offer.CianID, result.DistanceMeters, want)
+		}
+	}
+
+	if results := index.Radius(centerLng, centerLat, 50, Filter{}); len(results) != 0 {
+		t.Errorf("got offers %v in 50 m", getIDs(results))
+	}
+}
+
+func TestBbox(t *testing.T) {
+	offers := []cian.Offer{
+		newOffer(3, 49.11, 55.79),
+		newOffer(1, 49.13, 55.77),
+		// on border
+		newOffer(2, 49.10, 55.785),
+		newOffer(4, 49.16, 55.78),
+		newOffer(5, 49.12, 55.81),
+	}
+
+	index, err := New(offers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := index.Bbox(geos.NewBounds(49.10, 55.76, 49.15, 55.80), Filter{})
+	if got, want := getIDs(results), []int64{1, 2, 3}; !slices.Equal(got, want) {
+		t.Errorf("got offers %v, want %v ordered by id", got, want)
+	}
+}
+
+func TestPolygon(t *testing.T) {
+	offers := []cian.Offer{
+		newOffer(1, 49.11, 55.771),
+		// on border
+		newOffer(2, 49.12, 55.77),
+		// inside of polygon bounds, outside of triangle
+		newOffer(3, 49.139, 55.789),
+		newOffer(4, 49.20, 55.78),
+	}
+
+	index, err := New(offers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := index.Polygon(`{"type":"Polygon","coordinates":[[[49.10,55.77],[49.14,55.77],[49.10,55.79],[49.10,55.77]]]}`, Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := getIDs(results), []int64{1, 2}; !slices.Equal(got, want) {
+		t.Errorf("got offers %v, want %v", got, want)
+	}
+
+	if _, err := index.Polygon("not a polygon", Filter{}); err == nil {
+		t.Error("bad polygon is accepted")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	offer := newOffer(1, centerLng, centerLat)
+	offer.IsByHomeowner = true
+
+	badArea := offer
+	badArea.TotalArea = ""
+
+	tests := []struct {
+		name   string
+		filter Filter
+		offer  cian.Offer
+		want   bool
+	}{
+		{"empty", Filter{}, offer, true},
+		{"rooms", Filter{RoomsCounts: []int{1, 2}}, offer, true},
+		{"other rooms", Filter{RoomsCounts: []int{1, 3}}, offer, false},
+		{"category", Filter{Category: "flatSale"}, offer, true},
+		{"other category", Filter{Category: "flatRent"}, offer, false},
+		{"seller", Filter{SellerType: cian.SellerTypeOwner}, offer, true},
+		{"other seller", Filter{SellerType: cian.SellerTypeAgent}, offer, false},
+		{"min price", Filter{MinPrice: 5_000_000}, offer, true},
+		{"above min price", Filter{MinPrice: 5_000_001}, offer, false},
+		{"max price", Filter{MaxPrice: 5_000_000}, offer, true},
+		{"below max price", Filter{MaxPrice: 4_999_999}, offer, false},
+		{"min price per meter", Filter{MinPricePerMeter: 100_000}, offer, true},
+		{"above min price per meter", Filter{MinPricePerMeter: 100_001}, offer, false},
+		{"max price per meter", Filter{MaxPricePerMeter: 100_000}, offer, true},
+		{"below max price per meter", Filter{MaxPricePerMeter: 99_999}, offer, false},
+		{"price per meter without area", Filter{MaxPricePerMeter: 100_000}, badArea, false},
+		{"price without area", Filter{MaxPrice: 5_000_000}, badArea, true},
+	}
+
+	for _, test := range tests {
+		if got := test.filter.Match(test.offer); got != test.want {
+			t.Errorf("%s: got %t, want %t", test.name, got, test.want)
+		}
+	}
+
+	index, err := New([]cian.Offer{offer, offsetOffer(2, 100, 0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := index.Radius(centerLng, centerLat, 1000, Filter{SellerType: cian.SellerTypeOwner})
+	if got := getIDs(results); !slices.Equal(got, []int64{1}) {
+		t.Errorf("got offers %v, want filtered offer 1", got)
+	}
+}
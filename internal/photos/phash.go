@@ -0,0 +1,64 @@
+package photos
+
+import (
+	"image"
+	"math/bits"
+)
+
+const dhashWidth = 9
+const dhashHeight = 8
+
+func grayAt(img image.Image, x, y int) float64 {
+	r, g, b, _ := img.At(x, y).RGBA()
+	return 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+}
+
+// shrinkGray averages source pixels into width x height grayscale cells
+func shrinkGray(img image.Image, width, height int) [][]float64 {
+	bounds := img.Bounds()
+	cells := make([][]float64, height)
+
+	for cy := 0; cy < height; cy++ {
+		cells[cy] = make([]float64, width)
+
+		y1 := bounds.Min.Y + cy*bounds.Dy()/height
+		y2 := max(bounds.Min.Y+(cy+1)*bounds.Dy()/height, y1+1)
+
+		for cx := 0; cx < width; cx++ {
+			x1 := bounds.Min.X + cx*bounds.Dx()/width
+			x2 := max(bounds.Min.X+(cx+1)*bounds.Dx()/width, x1+1)
+
+			sum := 0.0
+			for y := y1; y < y2; y++ {
+				for x := x1; x < x2; x++ {
+					sum += grayAt(img, x, y)
+				}
+			}
+
+			cells[cy][cx] = sum / float64((y2-y1)*(x2-x1))
+		}
+	}
+
+	return cells
+}
+
+// DHash returns difference hash of image, similar images have hashes with small hamming distance
+func DHash(img image.Image) uint64 {
+	cells := shrinkGray(img, dhashWidth, dhashHeight)
+
+	var hash uint64
+	for y := 0; y < dhashHeight; y++ {
+		for x := 0; x < dhashWidth-1; x++ {
+			hash <<= 1
+			if cells[y][x] < cells[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+
+	return hash
+}
+
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
@@ -0,0 +1,256 @@
+package photos
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+const indexFileName = "index.json"
+
+// MaxRelistingDistance is max hamming distance between hashes of the same photo,
+// it must be less than number of hash bands to make band lookup exact
+const MaxRelistingDistance = 3
+
+const hashBands = 4
+
+type Photo struct {
+	Hash  string `json:"hash"`
+	PHash uint64 `json:"phash"`
+}
+
+type offerRecord struct {
+	FirstSeen time.Time `json:"first_seen"`
+	PHashes   []uint64  `json:"phashes"`
+	// Relisted has previous offers which were already reported as relisted by this offer
+	Relisted []int64 `json:"relisted,omitempty"`
+}
+
+type storeIndex struct {
+	Photos map[string]Photo       `json:"photos"`
+	Offers map[int64]*offerRecord `json:"offers"`
+}
+
+type Relisting struct {
+	OfferID         int64
+	PreviousOfferID int64
+	SharedPhotos    int
+}
+
+// Store keeps photos in directory by content hash and remembers perceptual hashes of offers photos
+type Store struct {
+	dir   string
+	mu    sync.Mutex
+	index storeIndex
+}
+
+func NewStore(dir string) (*Store, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("can't create photos directory: %w", err)
+	}
+
+	store := &Store{
+		dir: dir,
+		index: storeIndex{
+			Photos: make(map[string]Photo),
+			Offers: make(map[int64]*offerRecord),
+		},
+	}
+
+	indexJSON, err := os.ReadFile(filepath.Join(dir, indexFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't read photos index: %w", err)
+	}
+
+	err = json.Unmarshal(indexJSON, &store.index)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse photos index: %w", err)
+	}
+
+	return store, nil
+}
+
+func (s *Store) photoPath(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+// Fetch returns stored photo by url or downloads and stores it
+func (s *Store) Fetch(url string, download func(url string) ([]byte, error)) (Photo, error) {
+	s.mu.Lock()
+	photo, ok := s.index.Photos[url]
+	s.mu.Unlock()
+
+	if ok {
+		if _, err := os.Stat(s.photoPath(photo.Hash)); err == nil {
+			return photo, nil
+		}
+	}
+
+	data, err := download(url)
+	if err != nil {
+		return Photo{}, fmt.Errorf("can't download photo: %w", err)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Photo{}, fmt.Errorf("can't decode photo: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	photo = Photo{
+		Hash:  hex.EncodeToString(sum[:]),
+		PHash: DHash(img),
+	}
+
+	path := s.photoPath(photo.Hash)
+	if _, err := os.Stat(path); err != nil {
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			return Photo{}, fmt.Errorf("can't create photo directory: %w", err)
+		}
+
+		err = os.WriteFile(path, data, 0o644)
+		if err != nil {
+			return Photo{}, fmt.Errorf("can't write photo: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	s.index.Photos[url] = photo
+	s.mu.Unlock()
+
+	return photo, nil
+}
+
+// AddOffer remembers perceptual hashes of offer photos, first seen time is kept from the first call
+func (s *Store) AddOffer(offerID int64, seenAt time.Time, phashes []uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.index.Offers[offerID]
+	if !ok {
+		s.index.Offers[offerID] = &offerRecord{FirstSeen: seenAt, PHashes: phashes}
+		return
+	}
+
+	record.PHashes = phashes
+}
+
+func hashBand(phash uint64, band int) uint64 {
+	return uint64(band)<<16 | (phash>>(band*16))&0xffff
+}
+
+// FindRelistings returns offers which share photos with offers first seen earlier,
+// returned pairs are remembered and not returned again after index is saved
+func (s *Store) FindRelistings(offerIDs []int64) []Relisting {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bands := make(map[uint64][]int64)
+	for id, record := range s.index.Offers {
+		for _, phash := range record.PHashes {
+			for band := 0; band < hashBands; band++ {
+				key := hashBand(phash, band)
+				bands[key] = append(bands[key], id)
+			}
+		}
+	}
+
+	relistings := make([]Relisting, 0)
+	for _, id := range offerIDs {
+		record, ok := s.index.Offers[id]
+		if !ok {
+			continue
+		}
+
+		shared := make(map[int64]int)
+		for _, phash := range record.PHashes {
+			matched := make(map[int64]struct{})
+			for band := 0; band < hashBands; band++ {
+				for _, otherID := range bands[hashBand(phash, band)] {
+					if otherID == id {
+						continue
+					}
+					if _, ok := matched[otherID]; ok {
+						continue
+					}
+
+					other := s.index.Offers[otherID]
+					if !other.FirstSeen.Before(record.FirstSeen) {
+						continue
+					}
+
+					for _, otherPHash := range other.PHashes {
+						if HammingDistance(phash, otherPHash) <= MaxRelistingDistance {
+							matched[otherID] = struct{}{}
+							shared[otherID]++
+							break
+						}
+					}
+				}
+			}
+		}
+
+		for otherID, count := range shared {
+			if slices.Contains(record.Relisted, otherID) {
+				continue
+			}
+
+			record.Relisted = append(record.Relisted, otherID)
+			relistings = append(relistings, Relisting{
+				OfferID:         id,
+				PreviousOfferID: otherID,
+				SharedPhotos:    count,
+			})
+		}
+	}
+
+	sort.Slice(relistings, func(i, j int) bool {
+		if relistings[i].OfferID != relistings[j].OfferID {
+			return relistings[i].OfferID < relistings[j].OfferID
+		}
+		return relistings[i].PreviousOfferID < relistings[j].PreviousOfferID
+	})
+
+	return relistings
+}
+
+// Save writes index to photos directory
+func (s *Store) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	indexJSON, err := json.Marshal(s.index)
+	if err != nil {
+		return fmt.Errorf("can't marshal photos index: %w", err)
+	}
+
+	tmpPath := filepath.Join(s.dir, indexFileName+".tmp")
+	err = os.WriteFile(tmpPath, indexJSON, 0o644)
+	if err != nil {
+		return fmt.Errorf("can't write photos index: %w", err)
+	}
+
+	err = os.Rename(tmpPath, filepath.Join(s.dir, indexFileName))
+	if err != nil {
+		return fmt.Errorf("can't replace photos index: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package photos
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+)
+
+// gradientPNG returns png with horizontal gradient, reversed gradient has opposite dhash
+func gradientPNG(t *testing.T, reversed bool, brightness uint8) []byte {
+	t.Helper()
+
+	img := image.NewGray(image.Rect(0, 0, 90, 80))
+	for y := 0; y < 80; y++ {
+		for x := 0; x < 90; x++ {
+			value := x * 2
+			if reversed {
+				value = 180 - value
+			}
+			img.SetGray(x, y, color.Gray{Y: uint8(value/2) + brightness})
+		}
+	}
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestDHash(t *testing.T) {
+	decode := func(data []byte) image.Image {
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return img
+	}
+
+	hash := DHash(decode(gradientPNG(t, false, 0)))
+	brighter := DHash(decode(gradientPNG(t, false, 60)))
+	reversed := DHash(decode(gradientPNG(t, true, 0)))
+
+	if distance := HammingDistance(hash, brighter); distance > MaxRelistingDistance {
+		t.Errorf("brighter copy has distance %d", distance)
+	}
+	if distance := HammingDistance(hash, reversed); distance <= MaxRelistingDistance {
+		t.Errorf("different image has distance %d", distance)
+	}
+}
+
+func TestFetchSkipsStoredPhoto(t *testing.T) {
+	store, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	downloads := 0
+	download := func(url string) ([]byte, error) {
+		downloads++
+		return gradientPNG(t, false, 0), nil
+	}
+
+	first, err := store.Fetch("https://example.com/1.png", download)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := store.Fetch("https://example.com/1.png", download)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if downloads != 1 {
+		t.Errorf("photo is downloaded %d times", downloads)
+	}
+	if first != second {
+		t.Errorf("got %v for stored photo, want %v", second, first)
+	}
+}
+
+func TestFindRelistingsOnce(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seenAt := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	store.AddOffer(1, seenAt, []uint64{0x0f0f0f0f0f0f0f0f, 0x1234})
+	store.AddOffer(2, seenAt.Add(24*time.Hour), []uint64{0x0f0f0f0f0f0f0f0e, 0x1234})
+	store.AddOffer(3, seenAt.Add(24*time.Hour), []uint64{0xf0f0f0f0f0f0f0f0})
+
+	relistings := store.FindRelistings([]int64{1, 2, 3})
+	if len(relistings) != 1 || relistings[0] != (Relisting{OfferID: 2, PreviousOfferID: 1, SharedPhotos: 2}) {
+		t.Fatalf("got relistings %v, want offer 2 relisting offer 1 with 2 photos", relistings)
+	}
+
+	err = store.Save()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if relistings := store.FindRelistings([]int64{1, 2, 3}); len(relistings) != 0 {
+		t.Errorf("got relistings %v on next run, want none", relistings)
+	}
+}
@@ -0,0 +1,40 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter spaces calls of Wait evenly, nil limiter doesn't limit anything
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func NewRateLimiter(requestsPerSecond float64) *RateLimiter {
+	if requestsPerSecond <= 0 {
+		return nil
+	}
+
+	return &RateLimiter{
+		interval: time.Duration(float64(time.Second) / requestsPerSecond),
+	}
+}
+
+func (l *RateLimiter) Wait() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+
+	time.Sleep(wait)
+}
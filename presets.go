@@ -0,0 +1,9 @@
+// Package cianparser bundles area presets which can be used instead of polygon file
+package cianparser
+
+import "embed"
+
+// Presets has <name>.geojson files for named areas
+//
+//go:embed kazan.geojson moscow.geojson
+var Presets embed.FS